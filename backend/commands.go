@@ -0,0 +1,405 @@
+//go:build opencv
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/kai5263499/droidcam-sentry/backend/internal/config"
+	"github.com/kai5263499/droidcam-sentry/backend/internal/motion"
+	"github.com/kai5263499/droidcam-sentry/backend/internal/recorder"
+	"github.com/kai5263499/droidcam-sentry/backend/pkg/camera"
+	"gocv.io/x/gocv"
+)
+
+// runValidateConfig loads the configuration and reports any field errors
+func runValidateConfig(opts options, _ []string) error {
+	_, err := config.Load(opts.configPath)
+
+	var verr *config.ValidationError
+	if errors.As(err, &verr) {
+		fmt.Fprintf(os.Stderr, "%s is invalid:\n", opts.configPath)
+		for _, fe := range verr.Errors {
+			fmt.Fprintf(os.Stderr, "  %s: %s\n", fe.Field, fe.Message)
+		}
+		os.Exit(1)
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s is valid\n", opts.configPath)
+	return nil
+}
+
+// listAll returns the recordings of every configured camera, or only of
+// cameraName when set
+func listAll(cfg *config.Config, cameraName string, list func(camera, dir string) ([]recorder.Recording, error)) ([]recorder.Recording, error) {
+	all := make([]recorder.Recording, 0)
+	for _, camCfg := range cfg.Get().Cameras {
+		if cameraName != "" && camCfg.Name != cameraName {
+			continue
+		}
+		recordings, err := list(camCfg.Name, camCfg.Recording.Path)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, recordings...)
+	}
+	return all, nil
+}
+
+// printRecordings writes recordings as a table
+func printRecordings(recordings []recorder.Recording) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "CAMERA\tTIMESTAMP\tSIZE\tPATH")
+	for _, rec := range recordings {
+		fmt.Fprintf(w, "%s\t%s\t%.2f MB\t%s\n",
+			rec.Camera, rec.ModTime.Format("2006-01-02 15:04:05"), float64(rec.Size)/(1024*1024), rec.Path)
+	}
+	_ = w.Flush()
+}
+
+func runListRecordings(opts options, args []string) error {
+	flags := flag.NewFlagSet("list-recordings", flag.ExitOnError)
+	cameraName := flags.String("camera", "", "Only list recordings of this camera")
+	asJSON := flags.Bool("json", false, "Print JSON instead of a table")
+	_ = flags.Parse(args)
+
+	cfg, err := loadConfig(opts)
+	if err != nil {
+		return err
+	}
+
+	recordings, err := listAll(cfg, *cameraName, recorder.List)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(recordings)
+	}
+
+	printRecordings(recordings)
+	return nil
+}
+
+func runPurge(opts options, args []string) error {
+	flags := flag.NewFlagSet("purge", flag.ExitOnError)
+	dryRun := flags.Bool("dry-run", false, "List recordings that would be deleted without deleting them")
+	olderThan := flags.Duration("older-than", 0, "Delete recordings older than this (default: storage.retention_days)")
+	cameraName := flags.String("camera", "", "Only purge recordings of this camera")
+	_ = flags.Parse(args)
+
+	cfg, err := loadConfig(opts)
+	if err != nil {
+		return err
+	}
+
+	age := *olderThan
+	if age == 0 {
+		days := cfg.Get().Storage.RetentionDays
+		if days <= 0 {
+			return errors.New("storage.retention_days is not set; pass -older-than")
+		}
+		age = time.Duration(days) * 24 * time.Hour
+	}
+
+	recordings, err := listAll(cfg, *cameraName, recorder.List)
+	if err != nil {
+		return err
+	}
+
+	purged, err := recorder.Purge(recordings, time.Now().Add(-age), *dryRun)
+	printRecordings(purged)
+
+	verb := "Deleted"
+	if *dryRun {
+		verb = "Would delete"
+	}
+	fmt.Printf("%s %d recording(s) older than %s\n", verb, len(purged), age)
+	return err
+}
+
+func runReindex(opts options, args []string) error {
+	flags := flag.NewFlagSet("reindex", flag.ExitOnError)
+	cameraName := flags.String("camera", "", "Only reindex recordings of this camera")
+	_ = flags.Parse(args)
+
+	cfg, err := loadConfig(opts)
+	if err != nil {
+		return err
+	}
+
+	// Finish conversions interrupted by a crash or restart
+	leftovers, err := listAll(cfg, *cameraName, recorder.ListUnconverted)
+	if err != nil {
+		return err
+	}
+	for _, rec := range leftovers {
+		mp4Path, err := recorder.ConvertToMP4(rec.Path)
+		if mp4Path == "" {
+			fmt.Fprintf(os.Stderr, "Failed to convert %s: %v\n", rec.Path, err)
+			continue
+		}
+		fmt.Printf("Converted %s -> %s\n", rec.Path, mp4Path)
+	}
+
+	recordings, err := listAll(cfg, *cameraName, recorder.List)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "CAMERA\tDURATION\tPATH")
+	for _, rec := range recordings {
+		duration := "Unknown"
+		if d, err := recorder.ProbeDuration(rec.Path); err == nil {
+			duration = recorder.FormatDuration(d)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", rec.Camera, duration, rec.Path)
+	}
+	_ = w.Flush()
+
+	fmt.Printf("Indexed %d recording(s), converted %d leftover AVI file(s)\n", len(recordings), len(leftovers))
+	return nil
+}
+
+func runSnapshot(opts options, args []string) error {
+	flags := flag.NewFlagSet("snapshot", flag.ExitOnError)
+	output := flags.String("o", "", "Output JPEG path (default: <camera>_<timestamp>.jpg)")
+	_ = flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		return errors.New("usage: snapshot [-o file.jpg] <camera>")
+	}
+	name := flags.Arg(0)
+
+	cfg, err := loadConfig(opts)
+	if err != nil {
+		return err
+	}
+
+	camCfg, err := cfg.Camera(name)
+	if err != nil {
+		return err
+	}
+
+	stream := camera.NewStream(camCfg.Name, camCfg.URL)
+	if err := stream.Open(); err != nil {
+		return err
+	}
+	defer stream.Close()
+
+	frame, err := stream.ReadFrame()
+	if err != nil {
+		return err
+	}
+	defer frame.Close()
+
+	path := *output
+	if path == "" {
+		path = fmt.Sprintf("%s_%s.jpg", camCfg.Name, time.Now().Format("20060102_150405"))
+	}
+	if !gocv.IMWrite(path, frame) {
+		return fmt.Errorf("failed to write %s", path)
+	}
+
+	fmt.Printf("Saved snapshot of %s to %s\n", camCfg.Name, path)
+	return nil
+}
+
+func runReplay(opts options, args []string) error {
+	flags := flag.NewFlagSet("replay", flag.ExitOnError)
+	cameraName := flags.String("camera", "", "Camera whose settings to start from (default: from the file name)")
+	since := flags.Duration("since", 7*24*time.Hour, "Without files, replay the camera's recordings from this far back")
+	motionJSON := flags.String("motion", "", `Candidate motion settings as JSON, e.g. '{"algorithm":"knn","min_area":800}'`)
+	threshold := flags.Float64("threshold", 0, "Candidate motion_threshold in pixels")
+	thresholdPercent := flags.Float64("threshold-percent", 0, "Candidate motion_threshold_percent")
+	format := flags.String("format", "json", "Output format: json or csv")
+	out := flags.String("out", "", "Output file for json, or directory for csv (timeline.csv and events.csv); default stdout")
+	_ = flags.Parse(args)
+
+	if *format != "json" && *format != "csv" {
+		return fmt.Errorf("unknown format %q (use json or csv)", *format)
+	}
+
+	cfg, err := loadConfig(opts)
+	if err != nil {
+		return err
+	}
+	snap := cfg.Get()
+
+	files := flags.Args()
+	if len(files) == 0 {
+		if *cameraName == "" {
+			return errors.New("usage: replay [-camera name] [-motion json] [-format json|csv] [-out path] <file>... (or -camera with -since)")
+		}
+		recordings, err := listAll(cfg, *cameraName, recorder.List)
+		if err != nil {
+			return err
+		}
+		cutoff := time.Now().Add(-*since)
+		for _, rec := range recordings {
+			if rec.ModTime.After(cutoff) {
+				files = append(files, rec.Path)
+			}
+		}
+		if len(files) == 0 {
+			return fmt.Errorf("no recordings of %s in the last %s", *cameraName, *since)
+		}
+	}
+
+	var override config.MotionConfig
+	if *motionJSON != "" {
+		if err := config.DecodeJSON([]byte(*motionJSON), &override, "motion"); err != nil {
+			return err
+		}
+	}
+
+	reports := make([]motion.ReplayReport, 0, len(files))
+	for _, path := range files {
+		camCfg, err := replayCamera(snap, *cameraName, path)
+		if err != nil {
+			return err
+		}
+
+		// Apply the candidate parameters over the camera's own
+		camCfg.Motion = camCfg.Motion.Merge(override)
+		if *threshold > 0 {
+			camCfg.MotionThreshold = *threshold
+		}
+		if *thresholdPercent > 0 {
+			camCfg.MotionThresholdPercent = *thresholdPercent
+		}
+		if err := config.ValidateCamera(camCfg); err != nil {
+			return err
+		}
+
+		post := time.Duration(camCfg.Recording.PostBufferSeconds) * time.Second
+		report, err := motion.ReplayFile(path, camCfg.Name, motion.SettingsFromConfig(camCfg, snap.Motion),
+			time.Duration(camCfg.Recording.PreBufferSeconds)*time.Second, post)
+		if err != nil {
+			return err
+		}
+		// Prefer the event the recorder wrote down over the estimate
+		if meta, err := recorder.ReadMetadata(path); err == nil {
+			if r, ok := meta.EventRange(report.Duration.Seconds(), post.Seconds()); ok {
+				report.SetRecorded(motion.Span{
+					Start: time.Duration(r.Start * float64(time.Second)),
+					End:   time.Duration(r.End * float64(time.Second)),
+				}, motion.RecordedMetadata)
+			}
+		}
+		reports = append(reports, *report)
+		printReplaySummary(report)
+	}
+
+	if *format == "csv" {
+		return writeReplayCSV(*out, reports)
+	}
+
+	w := os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(reports)
+}
+
+// replayCamera returns the configuration of the camera a recording belongs
+// to: the named one, or the one named in the file name
+func replayCamera(snap config.Snapshot, cameraName, path string) (config.CameraConfig, error) {
+	base := filepath.Base(path)
+	if cameraName == "" {
+		name, ok := recordingCamera(base)
+		if !ok {
+			return config.CameraConfig{}, fmt.Errorf("cannot tell which camera recorded %s; pass -camera", base)
+		}
+		cameraName = name
+	}
+	for _, camCfg := range snap.Cameras {
+		if camCfg.Name == cameraName {
+			return camCfg, nil
+		}
+	}
+	return config.CameraConfig{}, fmt.Errorf("camera %s: %w", cameraName, config.ErrCameraNotFound)
+}
+
+// recordingCamera parses the camera name off a recording's file name,
+// <camera>_<YYYYMMDD>_<HHMMSS>.<ext>. The timestamp is taken off the end
+// since camera names may contain underscores themselves.
+func recordingCamera(base string) (string, bool) {
+	const stamp = "_20060102_150405"
+	name := strings.TrimSuffix(base, filepath.Ext(base))
+	if len(name) <= len(stamp) {
+		return "", false
+	}
+	cut := len(name) - len(stamp)
+	if _, err := time.Parse(stamp, name[cut:]); err != nil {
+		return "", false
+	}
+	return name[:cut], true
+}
+
+// printReplaySummary writes one line per replayed file to stderr so it
+// doesn't mix with the report
+func printReplaySummary(r *motion.ReplayReport) {
+	result := fmt.Sprintf("detected (delay %s)", r.Comparison.Delay.Round(100*time.Millisecond))
+	if r.Comparison.Missed {
+		result = "MISSED"
+	}
+	fmt.Fprintf(os.Stderr, "%s: %d frames, %d event(s), recorded event %s, %d extra\n",
+		r.File, r.Frames, len(r.Events), result, len(r.Comparison.Extra))
+}
+
+// writeReplayCSV writes timeline.csv and events.csv into dir, or both to
+// stdout one after the other when dir is empty
+func writeReplayCSV(dir string, reports []motion.ReplayReport) error {
+	if dir == "" {
+		if err := motion.WriteTimelineCSV(os.Stdout, reports); err != nil {
+			return err
+		}
+		fmt.Println()
+		return motion.WriteEventsCSV(os.Stdout, reports)
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	for name, write := range map[string]func(io.Writer, []motion.ReplayReport) error{
+		"timeline.csv": motion.WriteTimelineCSV,
+		"events.csv":   motion.WriteEventsCSV,
+	} {
+		f, err := os.Create(filepath.Join(dir, name))
+		if err != nil {
+			return err
+		}
+		err = write(f, reports)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(os.Stderr, "Wrote timeline.csv and events.csv to %s\n", dir)
+	return nil
+}
@@ -0,0 +1,78 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrCameraNotFound is returned when a camera name is not configured.
+	ErrCameraNotFound = errors.New("camera not found")
+	// ErrCameraExists is returned when adding a camera whose name is taken.
+	ErrCameraExists = errors.New("camera already exists")
+)
+
+// Camera returns a copy of the named camera's configuration
+func (c *Config) Camera(name string) (CameraConfig, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if i := c.cameraIndex(name); i >= 0 {
+		return c.Cameras[i].clone(), nil
+	}
+	return CameraConfig{}, fmt.Errorf("%w: %s", ErrCameraNotFound, name)
+}
+
+// AddCamera appends a new camera to the configuration
+func (c *Config) AddCamera(cam CameraConfig, dryRun bool) ([]Change, error) {
+	return c.Modify(func(s *Snapshot) error {
+		if findCamera(s.Cameras, cam.Name) >= 0 {
+			return fmt.Errorf("%w: %s", ErrCameraExists, cam.Name)
+		}
+		s.Cameras = append(s.Cameras, cam)
+		return nil
+	}, dryRun)
+}
+
+// ReplaceCamera replaces the configuration of the named camera. The camera
+// may be renamed as long as the new name is not already taken.
+func (c *Config) ReplaceCamera(name string, cam CameraConfig, dryRun bool) ([]Change, error) {
+	return c.Modify(func(s *Snapshot) error {
+		i := findCamera(s.Cameras, name)
+		if i < 0 {
+			return fmt.Errorf("%w: %s", ErrCameraNotFound, name)
+		}
+		if cam.Name != name && findCamera(s.Cameras, cam.Name) >= 0 {
+			return fmt.Errorf("%w: %s", ErrCameraExists, cam.Name)
+		}
+		s.Cameras[i] = cam
+		return nil
+	}, dryRun)
+}
+
+// RemoveCamera deletes the named camera from the configuration
+func (c *Config) RemoveCamera(name string, dryRun bool) ([]Change, error) {
+	return c.Modify(func(s *Snapshot) error {
+		i := findCamera(s.Cameras, name)
+		if i < 0 {
+			return fmt.Errorf("%w: %s", ErrCameraNotFound, name)
+		}
+		s.Cameras = append(s.Cameras[:i], s.Cameras[i+1:]...)
+		return nil
+	}, dryRun)
+}
+
+// cameraIndex returns the index of the named camera, or -1. Callers must hold
+// the lock.
+func (c *Config) cameraIndex(name string) int {
+	return findCamera(c.Cameras, name)
+}
+
+func findCamera(cameras []CameraConfig, name string) int {
+	for i := range cameras {
+		if cameras[i].Name == name {
+			return i
+		}
+	}
+	return -1
+}
@@ -2,7 +2,9 @@
 package config
 
 import (
+	"crypto/sha256"
 	"os"
+	"path/filepath"
 	"strconv"
 	"sync"
 
@@ -11,13 +13,21 @@ import (
 
 // Config holds the application configuration with thread-safe access.
 type Config struct {
-	Server      ServerConfig   `yaml:"server"`
-	Cameras     []CameraConfig `yaml:"cameras"`
-	Motion      MotionConfig   `yaml:"motion"`
-	Health      HealthConfig   `yaml:"health"`
-	Storage     StorageConfig  `yaml:"storage"`
-	mu          sync.RWMutex
-	subscribers []func(*Config)
+	Server        ServerConfig        `yaml:"server"`
+	Cameras       []CameraConfig      `yaml:"cameras"`
+	Motion        MotionConfig        `yaml:"motion"`
+	Health        HealthConfig        `yaml:"health"`
+	Storage       StorageConfig       `yaml:"storage"`
+	Objects       ObjectsConfig       `yaml:"objects"`
+	Faces         FacesConfig         `yaml:"faces"`
+	Tamper        TamperConfig        `yaml:"tamper"`
+	Notifications NotificationsConfig `yaml:"notifications"`
+	mu            sync.RWMutex
+	saveMu        sync.Mutex
+	subscribers   []func(*Config)
+	path          string
+	fileSum       [sha256.Size]byte
+	history       *History
 }
 
 // Snapshot is a thread-safe snapshot of Config without mutex
@@ -28,6 +38,11 @@ type Snapshot struct {
 	Motion  MotionConfig   `yaml:"motion" json:"motion"`
 	Health  HealthConfig   `yaml:"health" json:"health"`
 	Storage StorageConfig  `yaml:"storage" json:"storage"`
+	Objects ObjectsConfig  `yaml:"objects" json:"objects"`
+	Faces   FacesConfig    `yaml:"faces" json:"faces"`
+	Tamper  TamperConfig   `yaml:"tamper" json:"tamper"`
+	// Notifications are sent for motion events and alerts
+	Notifications NotificationsConfig `yaml:"notifications" json:"notifications"`
 }
 
 // ServerConfig contains HTTP server settings.
@@ -38,12 +53,45 @@ type ServerConfig struct {
 
 // CameraConfig contains individual camera settings.
 type CameraConfig struct {
-	Name            string          `yaml:"name" json:"name"`
-	Description     string          `yaml:"description" json:"description"`
-	URL             string          `yaml:"url" json:"url"`
-	Enabled         bool            `yaml:"enabled" json:"enabled"`
-	MotionThreshold float64         `yaml:"motion_threshold" json:"motion_threshold"`
-	Recording       RecordingConfig `yaml:"recording" json:"recording"`
+	Name            string  `yaml:"name" json:"name"`
+	Description     string  `yaml:"description" json:"description"`
+	URL             string  `yaml:"url" json:"url"`
+	Enabled         bool    `yaml:"enabled" json:"enabled"`
+	MotionThreshold float64 `yaml:"motion_threshold" json:"motion_threshold"`
+	// MotionThresholdPercent, when set, replaces MotionThreshold with a
+	// fraction (in percent) of the frame area, or of the zone area if zones
+	// are configured, so sensitivity does not depend on stream resolution.
+	MotionThresholdPercent float64             `yaml:"motion_threshold_percent,omitempty" json:"motion_threshold_percent,omitempty"`
+	Motion                 MotionConfig        `yaml:"motion,omitempty" json:"motion"`
+	Zones                  []ZoneConfig        `yaml:"zones,omitempty" json:"zones,omitempty"`
+	Tripwires              []TripwireConfig    `yaml:"tripwires,omitempty" json:"tripwires,omitempty"`
+	Overlay                OverlayConfig       `yaml:"overlay,omitempty" json:"overlay"`
+	Objects                CameraObjectsConfig `yaml:"objects,omitempty" json:"objects"`
+	Recording              RecordingConfig     `yaml:"recording" json:"recording"`
+	// Tamper overrides the global tamper detection switch for this camera
+	Tamper *bool `yaml:"tamper,omitempty" json:"tamper,omitempty"`
+	// Control holds rules that drive the phone's torch, zoom and focus
+	Control ControlConfig `yaml:"control,omitempty" json:"control"`
+}
+
+// OverlayConfig selects which outputs get motion boxes, zone outlines and
+// the motion score drawn onto them.
+type OverlayConfig struct {
+	Live      bool `yaml:"live,omitempty" json:"live"`
+	Recording bool `yaml:"recording,omitempty" json:"recording"`
+}
+
+// ZoneConfig is a polygon that limits where motion is counted. Points are
+// relative to the frame size (0-1 on each axis) so zones survive resolution
+// changes.
+type ZoneConfig struct {
+	Name   string       `yaml:"name" json:"name"`
+	Points [][2]float64 `yaml:"points" json:"points"`
+	// Dwell, when set, fires an event when an object stays in the zone
+	Dwell *DwellConfig `yaml:"dwell,omitempty" json:"dwell,omitempty"`
+	// Motion set to false keeps the zone out of the motion mask, for zones
+	// that only carry a dwell rule
+	Motion *bool `yaml:"motion,omitempty" json:"motion,omitempty"`
 }
 
 // RecordingConfig contains video recording settings.
@@ -54,22 +102,201 @@ type RecordingConfig struct {
 	PostBufferSeconds int    `yaml:"post_buffer_seconds" json:"post_buffer_seconds"`
 }
 
-// MotionConfig contains motion detection settings.
+// MotionConfig contains motion detection settings. The top-level block holds
+// the defaults; each camera may carry its own block in which unset (zero)
+// fields inherit from the defaults.
 type MotionConfig struct {
-	DetectionIntervalMs int `yaml:"detection_interval_ms" json:"detection_interval_ms"`
-	MinArea             int `yaml:"min_area" json:"min_area"`
+	DetectionIntervalMs int     `yaml:"detection_interval_ms,omitempty" json:"detection_interval_ms"`
+	MinArea             int     `yaml:"min_area,omitempty" json:"min_area"`
+	Algorithm           string  `yaml:"algorithm,omitempty" json:"algorithm,omitempty"`
+	History             int     `yaml:"history,omitempty" json:"history,omitempty"`
+	VarThreshold        float64 `yaml:"var_threshold,omitempty" json:"var_threshold,omitempty"`
+	DetectShadows       *bool   `yaml:"detect_shadows,omitempty" json:"detect_shadows,omitempty"`
+	KernelSize          int     `yaml:"kernel_size,omitempty" json:"kernel_size,omitempty"`
+	// ThresholdUnit selects how thresholds are expressed: "pixels" (default)
+	// or "percent". Cameras switched to percent that only have a pixel
+	// threshold are migrated once their stream resolution is known.
+	ThresholdUnit string `yaml:"threshold_unit,omitempty" json:"threshold_unit,omitempty"`
+	// Dist2Threshold is the KNN squared-distance threshold
+	Dist2Threshold float64 `yaml:"dist2_threshold,omitempty" json:"dist2_threshold,omitempty"`
+	// LearningRate is how quickly the running-average background adapts (0-1)
+	LearningRate float64 `yaml:"learning_rate,omitempty" json:"learning_rate,omitempty"`
+	// DiffThreshold is the grey-level change (0-255) a pixel or block must
+	// show to count as motion in the running_average and block_diff algorithms
+	DiffThreshold int `yaml:"diff_threshold,omitempty" json:"diff_threshold,omitempty"`
+	// BlockSize is the block edge length in pixels for block_diff
+	BlockSize int `yaml:"block_size,omitempty" json:"block_size,omitempty"`
+	// TriggerFrames of the last WindowFrames analysed frames must exceed the
+	// threshold before a motion event starts
+	TriggerFrames int `yaml:"trigger_frames,omitempty" json:"trigger_frames,omitempty"`
+	WindowFrames  int `yaml:"window_frames,omitempty" json:"window_frames,omitempty"`
+	// ContinueRatio scales the threshold that keeps an event going (0-1); an
+	// event ends once WindowFrames analysed frames in a row fall below it
+	ContinueRatio float64 `yaml:"continue_ratio,omitempty" json:"continue_ratio,omitempty"`
+	// SuppressLighting discards motion and re-learns the background when the
+	// whole frame changes brightness at once (lights, clouds, IR switching)
+	SuppressLighting *bool `yaml:"suppress_lighting,omitempty" json:"suppress_lighting,omitempty"`
+	// LightingDelta is the frame-wide mean brightness change (0-255) and
+	// LightingShift the histogram shift (0-1) between analysed frames that
+	// count as a lighting change
+	LightingDelta float64 `yaml:"lighting_delta,omitempty" json:"lighting_delta,omitempty"`
+	LightingShift float64 `yaml:"lighting_shift,omitempty" json:"lighting_shift,omitempty"`
+	// TrackMaxMissed is how many analysed frames a tracked object may go
+	// unseen before its track ends, and TrackMaxDistance how far it may move
+	// between analysed frames, as a fraction (0-1) of the frame diagonal
+	TrackMaxMissed   int     `yaml:"track_max_missed,omitempty" json:"track_max_missed,omitempty"`
+	TrackMaxDistance float64 `yaml:"track_max_distance,omitempty" json:"track_max_distance,omitempty"`
+	// Stationary keeps a slowly learned long-term background next to the
+	// motion model and reports regions that differ from it without moving
+	// for StationarySeconds as objects that appeared or were removed
+	Stationary        *bool `yaml:"stationary,omitempty" json:"stationary,omitempty"`
+	StationarySeconds int   `yaml:"stationary_seconds,omitempty" json:"stationary_seconds,omitempty"`
+	// LongTermRate is how quickly the long-term background adapts (0-1); it
+	// must be slow enough that objects aren't absorbed before they count
+	LongTermRate float64 `yaml:"long_term_rate,omitempty" json:"long_term_rate,omitempty"`
+}
+
+// Merge returns m with every field that is set in override replaced
+func (m MotionConfig) Merge(override MotionConfig) MotionConfig {
+	if override.DetectionIntervalMs > 0 {
+		m.DetectionIntervalMs = override.DetectionIntervalMs
+	}
+	if override.MinArea > 0 {
+		m.MinArea = override.MinArea
+	}
+	if override.Algorithm != "" {
+		m.Algorithm = override.Algorithm
+	}
+	if override.History > 0 {
+		m.History = override.History
+	}
+	if override.VarThreshold > 0 {
+		m.VarThreshold = override.VarThreshold
+	}
+	if override.DetectShadows != nil {
+		shadows := *override.DetectShadows
+		m.DetectShadows = &shadows
+	}
+	if override.KernelSize > 0 {
+		m.KernelSize = override.KernelSize
+	}
+	if override.ThresholdUnit != "" {
+		m.ThresholdUnit = override.ThresholdUnit
+	}
+	if override.Dist2Threshold > 0 {
+		m.Dist2Threshold = override.Dist2Threshold
+	}
+	if override.LearningRate > 0 {
+		m.LearningRate = override.LearningRate
+	}
+	if override.DiffThreshold > 0 {
+		m.DiffThreshold = override.DiffThreshold
+	}
+	if override.BlockSize > 0 {
+		m.BlockSize = override.BlockSize
+	}
+	if override.TriggerFrames > 0 {
+		m.TriggerFrames = override.TriggerFrames
+	}
+	if override.WindowFrames > 0 {
+		m.WindowFrames = override.WindowFrames
+	}
+	if override.ContinueRatio > 0 {
+		m.ContinueRatio = override.ContinueRatio
+	}
+	if override.SuppressLighting != nil {
+		suppress := *override.SuppressLighting
+		m.SuppressLighting = &suppress
+	}
+	if override.LightingDelta > 0 {
+		m.LightingDelta = override.LightingDelta
+	}
+	if override.LightingShift > 0 {
+		m.LightingShift = override.LightingShift
+	}
+	if override.TrackMaxMissed > 0 {
+		m.TrackMaxMissed = override.TrackMaxMissed
+	}
+	if override.TrackMaxDistance > 0 {
+		m.TrackMaxDistance = override.TrackMaxDistance
+	}
+	if override.Stationary != nil {
+		stationary := *override.Stationary
+		m.Stationary = &stationary
+	}
+	if override.StationarySeconds > 0 {
+		m.StationarySeconds = override.StationarySeconds
+	}
+	if override.LongTermRate > 0 {
+		m.LongTermRate = override.LongTermRate
+	}
+	return m
+}
+
+// EffectiveMotion returns the global motion defaults merged with the
+// camera's own motion block
+func (s Snapshot) EffectiveMotion(cam CameraConfig) MotionConfig {
+	return s.Motion.Merge(cam.Motion)
+}
+
+// clone returns a copy of cam that shares no pointers or slices with it
+func (cam CameraConfig) clone() CameraConfig {
+	cam.Motion = MotionConfig{}.Merge(cam.Motion)
+	cam.Objects = cam.Objects.clone()
+	cam.Control = cam.Control.clone()
+	if cam.Tamper != nil {
+		tamper := *cam.Tamper
+		cam.Tamper = &tamper
+	}
+	if cam.Tripwires != nil {
+		tripwires := make([]TripwireConfig, len(cam.Tripwires))
+		for i, t := range cam.Tripwires {
+			tripwires[i] = t.clone()
+		}
+		cam.Tripwires = tripwires
+	}
+	if cam.Zones != nil {
+		zones := make([]ZoneConfig, len(cam.Zones))
+		for i, z := range cam.Zones {
+			zones[i] = z.clone()
+		}
+		cam.Zones = zones
+	}
+	return cam
 }
 
 // HealthConfig contains health check settings.
 type HealthConfig struct {
 	CheckIntervalSeconds int `yaml:"check_interval_seconds" json:"check_interval_seconds"`
 	TimeoutSeconds       int `yaml:"timeout_seconds" json:"timeout_seconds"`
+	// StallSeconds is how long a stream may keep delivering unchanged frames
+	// before it's marked stalled and reconnected
+	StallSeconds int `yaml:"stall_seconds" json:"stall_seconds"`
+	// StallMaxDiff is the mean grey-level difference (0-255) between
+	// consecutive frames that still counts as unchanged; 0 means identical
+	StallMaxDiff float64 `yaml:"stall_max_diff" json:"stall_max_diff"`
+	// TelemetryHistory is how many phone telemetry samples (one per check)
+	// are kept per camera
+	TelemetryHistory int `yaml:"telemetry_history" json:"telemetry_history"`
+	// LowBatteryPercent, NotChargingMinutes and MaxTemperature (in °C) are
+	// the phone telemetry alert thresholds
+	LowBatteryPercent  float64 `yaml:"low_battery_percent" json:"low_battery_percent"`
+	NotChargingMinutes int     `yaml:"not_charging_minutes" json:"not_charging_minutes"`
+	MaxTemperature     float64 `yaml:"max_temperature" json:"max_temperature"`
 }
 
 // StorageConfig contains storage management settings.
 type StorageConfig struct {
-	MaxRecordingSizeMB int `yaml:"max_recording_size_mb" json:"max_recording_size_mb"`
-	RetentionDays      int `yaml:"retention_days" json:"retention_days"`
+	MaxRecordingSizeMB int    `yaml:"max_recording_size_mb" json:"max_recording_size_mb"`
+	RetentionDays      int    `yaml:"retention_days" json:"retention_days"`
+	StateFile          string `yaml:"state_file" json:"state_file"`
+	ConfigHistory      int    `yaml:"config_history" json:"config_history"`
+	// HeatmapDir holds each camera's accumulated motion heatmap, kept for
+	// HeatmapDays days
+	HeatmapDir  string `yaml:"heatmap_dir" json:"heatmap_dir"`
+	HeatmapDays int    `yaml:"heatmap_days" json:"heatmap_days"`
+	// CountersFile keeps the daily tripwire crossing counts
+	CountersFile string `yaml:"counters_file" json:"counters_file"`
 }
 
 // Load reads configuration from a YAML file and applies env var overrides
@@ -79,6 +306,36 @@ func Load(path string) (*Config, error) {
 		return nil, err
 	}
 
+	cfg, err := parse(data)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.path = path
+	cfg.fileSum = sha256.Sum256(data)
+	cfg.history = NewHistory(HistoryDir(path), cfg.Storage.ConfigHistory)
+
+	return cfg, nil
+}
+
+// parse decodes YAML configuration, applies env var overrides and defaults,
+// and validates the result
+func parse(data []byte) (*Config, error) {
+	cfg, err := decode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cfg.snapshot().Validate(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// decode parses YAML configuration and applies env var overrides and
+// defaults without validating it
+func decode(data []byte) (*Config, error) {
 	var cfg Config
 	if err := yaml.Unmarshal(data, &cfg); err != nil {
 		return nil, err
@@ -94,6 +351,25 @@ func Load(path string) (*Config, error) {
 	return &cfg, nil
 }
 
+// Path returns the file the configuration was loaded from
+func (c *Config) Path() string {
+	return c.path
+}
+
+// StoragePath resolves a storage file or directory: relative paths are taken
+// relative to the config file's directory, not the working directory
+func (c *Config) StoragePath(path string) string {
+	if path == "" || filepath.IsAbs(path) || c.path == "" {
+		return path
+	}
+	return filepath.Join(filepath.Dir(c.path), path)
+}
+
+// History returns the saved configuration versions
+func (c *Config) History() *History {
+	return c.history
+}
+
 func (c *Config) applyEnvOverrides() {
 	// Server overrides
 	if host := os.Getenv("DROIDCAM_SENTRY_HOST"); host != "" {
@@ -135,6 +411,11 @@ func (c *Config) applyEnvOverrides() {
 		}
 	}
 
+	// Runtime state file override
+	if stateFile := os.Getenv("DROIDCAM_SENTRY_STATE_FILE"); stateFile != "" {
+		c.Storage.StateFile = stateFile
+	}
+
 	// Pre-buffer override
 	if preBuffer := os.Getenv("DROIDCAM_SENTRY_PRE_BUFFER_SECONDS"); preBuffer != "" {
 		if pb, err := strconv.Atoi(preBuffer); err == nil {
@@ -168,17 +449,89 @@ func (c *Config) Get() Snapshot {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
+	return c.snapshot()
+}
+
+// snapshot copies the current values. Callers must hold the lock.
+func (c *Config) snapshot() Snapshot {
 	// Deep copy cameras to avoid shared references
 	cameras := make([]CameraConfig, len(c.Cameras))
-	copy(cameras, c.Cameras)
+	for i := range c.Cameras {
+		cameras[i] = c.Cameras[i].clone()
+	}
 
 	return Snapshot{
-		Server:  c.Server,
-		Cameras: cameras,
-		Motion:  c.Motion,
-		Storage: c.Storage,
-		Health:  c.Health,
+		Server:        c.Server,
+		Cameras:       cameras,
+		Motion:        MotionConfig{}.Merge(c.Motion),
+		Storage:       c.Storage,
+		Health:        c.Health,
+		Objects:       c.Objects,
+		Faces:         c.Faces,
+		Tamper:        c.Tamper,
+		Notifications: c.Notifications.clone(),
+	}
+}
+
+// Apply replaces every setting with the values in s and notifies subscribers
+func (c *Config) Apply(s Snapshot) {
+	c.Update(func(c *Config) {
+		c.apply(s)
+	})
+}
+
+// Modify applies fn to a copy of the configuration and validates the result.
+// Unless dryRun is set, a valid result is applied and subscribers are
+// notified. It returns the fields that changed (or would change).
+func (c *Config) Modify(fn func(*Snapshot) error, dryRun bool) ([]Change, error) {
+	if dryRun {
+		c.mu.RLock()
+		defer c.mu.RUnlock()
+
+		_, changes, err := c.modified(fn)
+		return changes, err
+	}
+
+	var changes []Change
+	var err error
+	c.Update(func(c *Config) {
+		var next Snapshot
+		if next, changes, err = c.modified(fn); err == nil {
+			c.apply(next)
+		}
+	})
+	return changes, err
+}
+
+// modified builds and validates the snapshot produced by fn. Callers must
+// hold the lock.
+func (c *Config) modified(fn func(*Snapshot) error) (Snapshot, []Change, error) {
+	current := c.snapshot()
+	next := c.snapshot()
+	if err := fn(&next); err != nil {
+		return Snapshot{}, nil, err
+	}
+
+	if err := next.Validate(); err != nil {
+		return Snapshot{}, nil, err
+	}
+	return next, Diff(current, next), nil
+}
+
+// apply copies s into c. Callers must hold the lock.
+func (c *Config) apply(s Snapshot) {
+	c.Server = s.Server
+	c.Cameras = make([]CameraConfig, len(s.Cameras))
+	for i := range s.Cameras {
+		c.Cameras[i] = s.Cameras[i].clone()
 	}
+	c.Motion = MotionConfig{}.Merge(s.Motion)
+	c.Health = s.Health
+	c.Storage = s.Storage
+	c.Objects = s.Objects
+	c.Faces = s.Faces
+	c.Tamper = s.Tamper
+	c.Notifications = s.Notifications.clone()
 }
 
 // Subscribe registers a callback for config changes
@@ -194,20 +547,12 @@ func (c *Config) notifySubscribers() {
 	}
 }
 
-// Save writes the current configuration to a file
-func (c *Config) Save(path string) error {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-
-	data, err := yaml.Marshal(c)
-	if err != nil {
-		return err
+func (c *Config) setDefaults() {
+	// Only an unset port gets the default; invalid ones fail validation
+	if c.Server.Port == 0 {
+		c.Server.Port = 8080
 	}
 
-	return os.WriteFile(path, data, 0o644)
-}
-
-func (c *Config) setDefaults() {
 	// Set default health check values if not configured
 	if c.Health.CheckIntervalSeconds <= 0 {
 		c.Health.CheckIntervalSeconds = 30
@@ -215,4 +560,155 @@ func (c *Config) setDefaults() {
 	if c.Health.TimeoutSeconds <= 0 {
 		c.Health.TimeoutSeconds = 5
 	}
+	if c.Health.StallSeconds <= 0 {
+		c.Health.StallSeconds = 15
+	}
+	if c.Health.TelemetryHistory <= 0 {
+		c.Health.TelemetryHistory = 240
+	}
+	if c.Health.LowBatteryPercent <= 0 {
+		c.Health.LowBatteryPercent = 20
+	}
+	if c.Health.NotChargingMinutes <= 0 {
+		c.Health.NotChargingMinutes = 5
+	}
+	if c.Health.MaxTemperature <= 0 {
+		c.Health.MaxTemperature = 45
+	}
+
+	// Motion defaults match OpenCV's MOG2 and KNN defaults
+	if c.Motion.Algorithm == "" {
+		c.Motion.Algorithm = AlgorithmMOG2
+	}
+	if c.Motion.History <= 0 {
+		c.Motion.History = 500
+	}
+	if c.Motion.VarThreshold <= 0 {
+		c.Motion.VarThreshold = 16
+	}
+	if c.Motion.DetectShadows == nil {
+		shadows := true
+		c.Motion.DetectShadows = &shadows
+	}
+	if c.Motion.KernelSize <= 0 {
+		c.Motion.KernelSize = 5
+	}
+	if c.Motion.Dist2Threshold <= 0 {
+		c.Motion.Dist2Threshold = 400
+	}
+	if c.Motion.LearningRate <= 0 {
+		c.Motion.LearningRate = 0.05
+	}
+	if c.Motion.DiffThreshold <= 0 {
+		c.Motion.DiffThreshold = 25
+	}
+	if c.Motion.BlockSize <= 0 {
+		c.Motion.BlockSize = 16
+	}
+	if c.Motion.TriggerFrames <= 0 {
+		c.Motion.TriggerFrames = 3
+	}
+	if c.Motion.WindowFrames <= 0 {
+		c.Motion.WindowFrames = 5
+	}
+	if c.Motion.ContinueRatio <= 0 {
+		c.Motion.ContinueRatio = 0.5
+	}
+	if c.Motion.SuppressLighting == nil {
+		suppress := true
+		c.Motion.SuppressLighting = &suppress
+	}
+	if c.Motion.LightingDelta <= 0 {
+		c.Motion.LightingDelta = 40
+	}
+	if c.Motion.LightingShift <= 0 {
+		c.Motion.LightingShift = 0.5
+	}
+	if c.Motion.TrackMaxMissed <= 0 {
+		c.Motion.TrackMaxMissed = 10
+	}
+	if c.Motion.TrackMaxDistance <= 0 {
+		c.Motion.TrackMaxDistance = 0.15
+	}
+	if c.Motion.StationarySeconds <= 0 {
+		c.Motion.StationarySeconds = 60
+	}
+	if c.Motion.LongTermRate <= 0 {
+		c.Motion.LongTermRate = 0.0005
+	}
+
+	// Runtime camera state lives next to the config by default (see
+	// StoragePath)
+	if c.Storage.StateFile == "" {
+		c.Storage.StateFile = "state.json"
+	}
+	if c.Storage.ConfigHistory <= 0 {
+		c.Storage.ConfigHistory = 20
+	}
+	if c.Storage.HeatmapDir == "" {
+		c.Storage.HeatmapDir = "heatmaps"
+	}
+	if c.Storage.HeatmapDays <= 0 {
+		c.Storage.HeatmapDays = 7
+	}
+	if c.Storage.CountersFile == "" {
+		c.Storage.CountersFile = "counters.json"
+	}
+
+	if c.Objects.Type == "" {
+		c.Objects.Type = ModelYOLO
+	}
+	if c.Objects.InputSize <= 0 {
+		c.Objects.InputSize = 640
+		if c.Objects.Type == ModelSSD {
+			c.Objects.InputSize = 300
+		}
+	}
+	if c.Objects.Confidence <= 0 {
+		c.Objects.Confidence = 0.5
+	}
+	if c.Objects.NMSThreshold <= 0 {
+		c.Objects.NMSThreshold = 0.45
+	}
+	if c.Objects.IntervalMs <= 0 {
+		c.Objects.IntervalMs = 1000
+	}
+
+	if c.Faces.Type == "" {
+		c.Faces.Type = FaceHaar
+	}
+	if c.Faces.Confidence <= 0 {
+		c.Faces.Confidence = 0.5
+	}
+	if c.Faces.MinSize <= 0 {
+		c.Faces.MinSize = 30
+	}
+	if c.Faces.IntervalMs <= 0 {
+		c.Faces.IntervalMs = 500
+	}
+	if c.Faces.Blocks <= 0 {
+		c.Faces.Blocks = 8
+	}
+	if c.Tamper.IntervalMs <= 0 {
+		c.Tamper.IntervalMs = 1000
+	}
+	if c.Tamper.HoldSeconds <= 0 {
+		c.Tamper.HoldSeconds = 5
+	}
+	if c.Tamper.MinStdDev <= 0 {
+		c.Tamper.MinStdDev = 10
+	}
+	if c.Tamper.DefocusRatio <= 0 {
+		c.Tamper.DefocusRatio = 0.3
+	}
+	if c.Tamper.MinSimilarity <= 0 {
+		c.Tamper.MinSimilarity = 0.5
+	}
+	if c.Tamper.ReferenceMinutes <= 0 {
+		c.Tamper.ReferenceMinutes = 10
+	}
+
+	if c.Notifications.TimeoutSeconds <= 0 {
+		c.Notifications.TimeoutSeconds = 10
+	}
 }
@@ -0,0 +1,92 @@
+package config
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/kai5263499/droidcam-sentry/backend/pkg/camera"
+)
+
+// Control rule triggers
+const (
+	TriggerMotionStart = "motion_start"
+	TriggerMotionEnd   = "motion_end"
+)
+
+// ControlConfig holds a camera's device control rules
+type ControlConfig struct {
+	Rules []ControlRule `yaml:"rules,omitempty" json:"rules,omitempty"`
+}
+
+// ControlRule sends a DroidCam control command when motion starts or ends,
+// e.g. switching the torch on for motion at night
+type ControlRule struct {
+	Trigger string `yaml:"trigger" json:"trigger"`
+	Action  string `yaml:"action" json:"action"`
+	Value   string `yaml:"value,omitempty" json:"value,omitempty"`
+	// Schedule limits the rule to these windows; without windows the rule
+	// is always active
+	Schedule []TimeWindow `yaml:"schedule,omitempty" json:"schedule,omitempty"`
+}
+
+// Command returns the control command the rule sends
+func (r ControlRule) Command() camera.Command {
+	return camera.Command{Action: r.Action, Value: r.Value}
+}
+
+// Active reports whether the rule applies at t
+func (r ControlRule) Active(t time.Time) bool {
+	if len(r.Schedule) == 0 {
+		return true
+	}
+	for _, w := range r.Schedule {
+		if w.Contains(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// Matching returns the rules for trigger that are active at t
+func (c ControlConfig) Matching(trigger string, t time.Time) []ControlRule {
+	var rules []ControlRule
+	for _, r := range c.Rules {
+		if r.Trigger == trigger && r.Active(t) {
+			rules = append(rules, r)
+		}
+	}
+	return rules
+}
+
+func (c ControlConfig) clone() ControlConfig {
+	if c.Rules == nil {
+		return c
+	}
+	rules := make([]ControlRule, len(c.Rules))
+	for i, r := range c.Rules {
+		if r.Schedule != nil {
+			schedule := make([]TimeWindow, len(r.Schedule))
+			for j, w := range r.Schedule {
+				schedule[j] = w.clone()
+			}
+			r.Schedule = schedule
+		}
+		rules[i] = r
+	}
+	return ControlConfig{Rules: rules}
+}
+
+func validateControl(verr *ValidationError, prefix string, c ControlConfig) {
+	for i, r := range c.Rules {
+		rprefix := fmt.Sprintf("%s.rules[%d]", prefix, i)
+		if r.Trigger != TriggerMotionStart && r.Trigger != TriggerMotionEnd {
+			verr.add(rprefix+".trigger", "unknown trigger %q (use %s or %s)", r.Trigger, TriggerMotionStart, TriggerMotionEnd)
+		}
+		if err := r.Command().Validate(); err != nil {
+			verr.add(rprefix+".action", "%v", err)
+		}
+		for j, w := range r.Schedule {
+			validateWindow(verr, fmt.Sprintf("%s.schedule[%d]", rprefix, j), w)
+		}
+	}
+}
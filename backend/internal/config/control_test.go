@@ -0,0 +1,43 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestControlMatching(t *testing.T) {
+	c := ControlConfig{Rules: []ControlRule{
+		{Trigger: TriggerMotionStart, Action: "torch", Value: "on", Schedule: []TimeWindow{{From: "20:00", To: "06:00"}}},
+		{Trigger: TriggerMotionEnd, Action: "torch", Value: "off"},
+		{Trigger: TriggerMotionStart, Action: "autofocus"},
+	}}
+
+	night := time.Date(2024, 5, 6, 23, 0, 0, 0, time.Local)
+	day := time.Date(2024, 5, 6, 12, 0, 0, 0, time.Local)
+	if rules := c.Matching(TriggerMotionStart, night); len(rules) != 2 {
+		t.Errorf("Expected two motion start rules at night, got %v", rules)
+	}
+	if rules := c.Matching(TriggerMotionStart, day); len(rules) != 1 || rules[0].Action != "autofocus" {
+		t.Errorf("Expected only autofocus during the day, got %v", rules)
+	}
+	if rules := c.Matching(TriggerMotionEnd, day); len(rules) != 1 {
+		t.Errorf("Expected the motion end rule, got %v", rules)
+	}
+}
+
+func TestValidateControl(t *testing.T) {
+	verr := &ValidationError{}
+	validateControl(verr, "control", ControlConfig{Rules: []ControlRule{
+		{Trigger: "sunset", Action: "torch", Value: "dim", Schedule: []TimeWindow{{From: "7pm", To: "06:00"}}},
+	}})
+
+	fields := map[string]bool{}
+	for _, e := range verr.Errors {
+		fields[e.Field] = true
+	}
+	for _, want := range []string{"control.rules[0].trigger", "control.rules[0].action", "control.rules[0].schedule[0].from"} {
+		if !fields[want] {
+			t.Errorf("Expected an error for %s, got %v", want, verr.Errors)
+		}
+	}
+}
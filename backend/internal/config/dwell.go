@@ -0,0 +1,84 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// DwellConfig is a loitering rule for a zone: it fires once an object has
+// stayed inside the zone for Seconds.
+type DwellConfig struct {
+	Seconds int `yaml:"seconds" json:"seconds"`
+	// Labels limits the rule to objects of these classes, as reported by
+	// object detection
+	Labels []string `yaml:"labels,omitempty" json:"labels,omitempty"`
+	// Record starts a recording when the rule fires; a notification is
+	// always sent
+	Record bool `yaml:"record,omitempty" json:"record,omitempty"`
+	// Schedule limits the rule to these windows, each optionally with its
+	// own threshold; without windows the rule is always active
+	Schedule []DwellWindow `yaml:"schedule,omitempty" json:"schedule,omitempty"`
+}
+
+// DwellWindow is a schedule window with an optional threshold of its own
+type DwellWindow struct {
+	TimeWindow `yaml:",inline"`
+	Seconds    int `yaml:"seconds,omitempty" json:"seconds,omitempty"`
+}
+
+// Threshold returns how long an object must dwell to fire the rule at t,
+// and false if the rule isn't active then
+func (d DwellConfig) Threshold(t time.Time) (time.Duration, bool) {
+	if len(d.Schedule) == 0 {
+		return time.Duration(d.Seconds) * time.Second, d.Seconds > 0
+	}
+	for _, w := range d.Schedule {
+		if !w.Contains(t) {
+			continue
+		}
+		seconds := d.Seconds
+		if w.Seconds > 0 {
+			seconds = w.Seconds
+		}
+		return time.Duration(seconds) * time.Second, seconds > 0
+	}
+	return 0, false
+}
+
+func (d *DwellConfig) clone() *DwellConfig {
+	if d == nil {
+		return nil
+	}
+	c := *d
+	c.Labels = cloneStrings(d.Labels)
+	if d.Schedule != nil {
+		c.Schedule = make([]DwellWindow, len(d.Schedule))
+		for i, w := range d.Schedule {
+			w.TimeWindow = w.TimeWindow.clone()
+			c.Schedule[i] = w
+		}
+	}
+	return &c
+}
+
+func validateDwell(verr *ValidationError, prefix string, d *DwellConfig) {
+	if d == nil {
+		return
+	}
+	if d.Seconds < 0 {
+		verr.add(prefix+".seconds", "must not be negative")
+	}
+	if d.Seconds == 0 && len(d.Schedule) == 0 {
+		verr.add(prefix+".seconds", "is required")
+	}
+	for i, w := range d.Schedule {
+		wprefix := fmt.Sprintf("%s.schedule[%d]", prefix, i)
+		validateWindow(verr, wprefix, w.TimeWindow)
+		if w.Seconds < 0 {
+			verr.add(wprefix+".seconds", "must not be negative")
+		}
+		if w.Seconds == 0 && d.Seconds == 0 {
+			verr.add(wprefix+".seconds", "is required when the rule has no seconds")
+		}
+	}
+}
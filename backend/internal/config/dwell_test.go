@@ -0,0 +1,58 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDwellThresholdWithoutSchedule(t *testing.T) {
+	d := DwellConfig{Seconds: 30}
+	if got, ok := d.Threshold(time.Now()); !ok || got != 30*time.Second {
+		t.Errorf("Expected an always active 30s rule, got %s %v", got, ok)
+	}
+}
+
+func TestDwellThresholdSchedule(t *testing.T) {
+	d := DwellConfig{
+		Seconds: 30,
+		Schedule: []DwellWindow{
+			{TimeWindow: TimeWindow{Days: []string{"mon", "tue", "wed", "thu", "fri"}, From: "22:00", To: "06:00"}, Seconds: 10},
+			{TimeWindow: TimeWindow{Days: []string{"sat", "sun"}, From: "00:00", To: "00:00"}},
+		},
+	}
+
+	// 2024-05-06 is a Monday
+	cases := []struct {
+		at     time.Time
+		want   time.Duration
+		active bool
+	}{
+		{time.Date(2024, 5, 6, 23, 0, 0, 0, time.Local), 10 * time.Second, true},
+		{time.Date(2024, 5, 7, 5, 59, 0, 0, time.Local), 10 * time.Second, true},
+		{time.Date(2024, 5, 7, 12, 0, 0, 0, time.Local), 0, false},
+		// Monday early morning belongs to Sunday night, which has no window
+		{time.Date(2024, 5, 6, 3, 0, 0, 0, time.Local), 0, false},
+		{time.Date(2024, 5, 11, 12, 0, 0, 0, time.Local), 30 * time.Second, true},
+	}
+	for _, c := range cases {
+		got, ok := d.Threshold(c.at)
+		if ok != c.active || got != c.want {
+			t.Errorf("At %s expected %s %v, got %s %v", c.at.Format("Mon 15:04"), c.want, c.active, got, ok)
+		}
+	}
+}
+
+func TestValidateDwell(t *testing.T) {
+	verr := &ValidationError{}
+	validateDwell(verr, "dwell", &DwellConfig{Schedule: []DwellWindow{{TimeWindow: TimeWindow{Days: []string{"someday"}, From: "25:00", To: "06:00"}}}})
+
+	fields := map[string]bool{}
+	for _, e := range verr.Errors {
+		fields[e.Field] = true
+	}
+	for _, want := range []string{"dwell.schedule[0].from", "dwell.schedule[0].days", "dwell.schedule[0].seconds"} {
+		if !fields[want] {
+			t.Errorf("Expected an error for %s, got %v", want, verr.Errors)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package config
+
+import "os"
+
+// Face detector types
+const (
+	FaceHaar = "haar"
+	FaceDNN  = "dnn"
+)
+
+// FacesConfig configures the optional face detection stage that marks when
+// faces appear in recordings, and the pixelation used by blurred exports.
+type FacesConfig struct {
+	// Enabled turns on marking faces while recording; blurred exports only
+	// need Model
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// Type is "haar" (an OpenCV cascade XML) or "dnn" (an SSD face model
+	// such as res10_300x300_ssd)
+	Type        string `yaml:"type" json:"type"`
+	Model       string `yaml:"model" json:"model"`
+	ModelConfig string `yaml:"model_config,omitempty" json:"model_config,omitempty"`
+	// Confidence is the minimum DNN detection confidence
+	Confidence float64 `yaml:"confidence" json:"confidence"`
+	// MinSize is the smallest face, in pixels, the cascade looks for
+	MinSize int `yaml:"min_size" json:"min_size"`
+	// IntervalMs is the minimum time between detections while recording
+	IntervalMs int `yaml:"interval_ms" json:"interval_ms"`
+	// Blocks is how many pixel blocks span a face in blurred exports
+	Blocks int `yaml:"blocks" json:"blocks"`
+}
+
+func validateFaces(verr *ValidationError, prefix string, f FacesConfig) {
+	if f.Type != "" && f.Type != FaceHaar && f.Type != FaceDNN {
+		verr.add(prefix+".type", "must be %q or %q", FaceHaar, FaceDNN)
+	}
+	if f.Confidence < 0 || f.Confidence > 1 {
+		verr.add(prefix+".confidence", "must be between 0 and 1")
+	}
+	if f.MinSize < 0 {
+		verr.add(prefix+".min_size", "must not be negative")
+	}
+	if f.IntervalMs < 0 {
+		verr.add(prefix+".interval_ms", "must not be negative")
+	}
+	if f.Blocks < 0 {
+		verr.add(prefix+".blocks", "must not be negative")
+	}
+	if !f.Enabled {
+		return
+	}
+
+	if f.Model == "" {
+		verr.add(prefix+".model", "is required when face detection is enabled")
+	} else if _, err := os.Stat(f.Model); err != nil {
+		verr.add(prefix+".model", "cannot read %s", f.Model)
+	}
+	if f.ModelConfig != "" {
+		if _, err := os.Stat(f.ModelConfig); err != nil {
+			verr.add(prefix+".model_config", "cannot read %s", f.ModelConfig)
+		}
+	}
+}
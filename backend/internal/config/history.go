@@ -0,0 +1,164 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// ErrVersionNotFound is returned when a config version is not in the history.
+var ErrVersionNotFound = errors.New("config version not found")
+
+// Version describes one saved configuration in the history.
+type Version struct {
+	Version   int       `json:"version"`
+	Timestamp time.Time `json:"timestamp"`
+	User      string    `json:"user"`
+	Size      int       `json:"size"`
+}
+
+// History keeps a bounded, on-disk list of previous configuration files.
+type History struct {
+	dir   string
+	limit int
+	mu    sync.Mutex
+}
+
+// HistoryDir returns the history directory used for a config file
+func HistoryDir(configPath string) string {
+	return filepath.Join(filepath.Dir(configPath), ".config-history")
+}
+
+// NewHistory creates a history stored in dir that keeps at most limit versions
+func NewHistory(dir string, limit int) *History {
+	return &History{
+		dir:   dir,
+		limit: limit,
+	}
+}
+
+// List returns the saved versions, newest first
+func (h *History) List() ([]Version, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	versions, err := h.readIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]Version, len(versions))
+	for i, v := range versions {
+		list[len(versions)-1-i] = v
+	}
+	return list, nil
+}
+
+// Read returns the raw YAML of a saved version
+func (h *History) Read(version int) ([]byte, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	data, err := os.ReadFile(h.versionPath(version))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %d", ErrVersionNotFound, version)
+	}
+	return data, err
+}
+
+// Record stores data as a new version and prunes versions beyond the limit
+func (h *History) Record(data []byte, user string) (Version, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.record(data, user)
+}
+
+// RecordInitial stores data as the first version if the history is empty
+func (h *History) RecordInitial(data []byte) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	versions, err := h.readIndex()
+	if err != nil || len(versions) > 0 {
+		return err
+	}
+
+	_, err = h.record(data, "initial")
+	return err
+}
+
+// record writes a version file and updates the index. Callers must hold the
+// lock.
+func (h *History) record(data []byte, user string) (Version, error) {
+	versions, err := h.readIndex()
+	if err != nil {
+		return Version{}, err
+	}
+
+	if err := os.MkdirAll(h.dir, 0o755); err != nil {
+		return Version{}, err
+	}
+
+	next := 1
+	if len(versions) > 0 {
+		next = versions[len(versions)-1].Version + 1
+	}
+
+	v := Version{
+		Version:   next,
+		Timestamp: time.Now(),
+		User:      user,
+		Size:      len(data),
+	}
+	if err := writeFileAtomic(h.versionPath(next), data); err != nil {
+		return Version{}, err
+	}
+	versions = append(versions, v)
+
+	// Drop the oldest versions beyond the limit
+	for h.limit > 0 && len(versions) > h.limit {
+		_ = os.Remove(h.versionPath(versions[0].Version))
+		versions = versions[1:]
+	}
+
+	return v, h.writeIndex(versions)
+}
+
+func (h *History) versionPath(version int) string {
+	return filepath.Join(h.dir, fmt.Sprintf("config.v%d.yaml", version))
+}
+
+func (h *History) indexPath() string {
+	return filepath.Join(h.dir, "index.json")
+}
+
+// readIndex returns the versions oldest first. Callers must hold the lock.
+func (h *History) readIndex() ([]Version, error) {
+	data, err := os.ReadFile(h.indexPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var versions []Version
+	if err := json.Unmarshal(data, &versions); err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+// writeIndex saves the version list. Callers must hold the lock.
+func (h *History) writeIndex(versions []Version) error {
+	data, err := json.MarshalIndent(versions, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(h.indexPath(), data)
+}
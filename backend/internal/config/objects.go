@@ -0,0 +1,126 @@
+package config
+
+import (
+	"net/url"
+	"os"
+)
+
+// Object detection model types
+const (
+	ModelYOLO = "yolo"
+	ModelSSD  = "ssd"
+)
+
+// ObjectsConfig configures the optional object detection stage that runs on
+// frames where motion was detected.
+type ObjectsConfig struct {
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// Model is an ONNX (or other gocv.ReadNet supported) model file and
+	// ModelConfig its optional network description (e.g. a Caffe prototxt)
+	Model       string `yaml:"model" json:"model"`
+	ModelConfig string `yaml:"model_config,omitempty" json:"model_config,omitempty"`
+	// Type is the output layout: "yolo" (YOLOv5/v8 ONNX) or "ssd"
+	// (MobileNet-SSD style detections)
+	Type string `yaml:"type" json:"type"`
+	// Labels is a file with one class name per line; COCO names are used
+	// when empty
+	Labels       string  `yaml:"labels,omitempty" json:"labels,omitempty"`
+	InputSize    int     `yaml:"input_size" json:"input_size"`
+	Confidence   float64 `yaml:"confidence" json:"confidence"`
+	NMSThreshold float64 `yaml:"nms_threshold" json:"nms_threshold"`
+	// Crops runs the model on each motion region instead of the whole frame
+	Crops bool `yaml:"crops" json:"crops"`
+	// IntervalMs is the minimum time between detections on one camera
+	IntervalMs int `yaml:"interval_ms" json:"interval_ms"`
+}
+
+// CameraObjectsConfig holds a camera's label allow-lists. When object
+// detection is enabled, motion only starts a recording (or a notification)
+// once an object with one of these labels is seen; an empty list doesn't
+// gate.
+type CameraObjectsConfig struct {
+	Record []string `yaml:"record,omitempty" json:"record,omitempty"`
+	Notify []string `yaml:"notify,omitempty" json:"notify,omitempty"`
+}
+
+// NotificationsConfig lists webhooks that receive event notifications as
+// JSON POSTs.
+type NotificationsConfig struct {
+	Webhooks       []string `yaml:"webhooks,omitempty" json:"webhooks,omitempty"`
+	TimeoutSeconds int      `yaml:"timeout_seconds" json:"timeout_seconds"`
+}
+
+func (o CameraObjectsConfig) clone() CameraObjectsConfig {
+	return CameraObjectsConfig{
+		Record: cloneStrings(o.Record),
+		Notify: cloneStrings(o.Notify),
+	}
+}
+
+func (n NotificationsConfig) clone() NotificationsConfig {
+	n.Webhooks = cloneStrings(n.Webhooks)
+	return n
+}
+
+func cloneStrings(s []string) []string {
+	if s == nil {
+		return nil
+	}
+	return append([]string(nil), s...)
+}
+
+func validateObjects(verr *ValidationError, prefix string, o ObjectsConfig) {
+	if o.Type != "" && o.Type != ModelYOLO && o.Type != ModelSSD {
+		verr.add(prefix+".type", "must be %q or %q", ModelYOLO, ModelSSD)
+	}
+	if o.InputSize < 0 {
+		verr.add(prefix+".input_size", "must not be negative")
+	}
+	if o.Confidence < 0 || o.Confidence > 1 {
+		verr.add(prefix+".confidence", "must be between 0 and 1")
+	}
+	if o.NMSThreshold < 0 || o.NMSThreshold > 1 {
+		verr.add(prefix+".nms_threshold", "must be between 0 and 1")
+	}
+	if o.IntervalMs < 0 {
+		verr.add(prefix+".interval_ms", "must not be negative")
+	}
+	if !o.Enabled {
+		return
+	}
+
+	for field, path := range map[string]string{"model": o.Model, "model_config": o.ModelConfig, "labels": o.Labels} {
+		if path == "" {
+			continue
+		}
+		if _, err := os.Stat(path); err != nil {
+			verr.add(prefix+"."+field, "cannot read %s", path)
+		}
+	}
+	if o.Model == "" {
+		verr.add(prefix+".model", "is required when object detection is enabled")
+	}
+}
+
+func validateCameraObjects(verr *ValidationError, prefix string, o CameraObjectsConfig) {
+	for field, labels := range map[string][]string{"record": o.Record, "notify": o.Notify} {
+		for _, label := range labels {
+			if label == "" {
+				verr.add(prefix+"."+field, "labels must not be empty")
+				break
+			}
+		}
+	}
+}
+
+func validateNotifications(verr *ValidationError, prefix string, n NotificationsConfig) {
+	for _, hook := range n.Webhooks {
+		u, err := url.Parse(hook)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			verr.add(prefix+".webhooks", "invalid webhook URL %q", hook)
+		}
+	}
+	if n.TimeoutSeconds < 0 {
+		verr.add(prefix+".timeout_seconds", "must not be negative")
+	}
+}
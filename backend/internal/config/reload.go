@@ -0,0 +1,115 @@
+package config
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"os"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// ErrUnchanged is returned by Reload when the file matches the running config.
+var ErrUnchanged = errors.New("config file unchanged")
+
+// Reload re-reads the config file and, if it is valid, applies it through
+// Update so subscribers see the new settings. It returns the fields that
+// changed. When the file is invalid the running configuration is left
+// untouched and the returned changes describe what the file would have
+// changed.
+func (c *Config) Reload() ([]Change, error) {
+	c.saveMu.Lock()
+	defer c.saveMu.Unlock()
+
+	data, err := os.ReadFile(c.path)
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(data)
+	if sum == c.fileSum {
+		return nil, ErrUnchanged
+	}
+
+	loaded, err := decode(data)
+	if err != nil {
+		return nil, err
+	}
+	next := loaded.snapshot()
+
+	if err := next.Validate(); err != nil {
+		return Diff(c.Get(), next), err
+	}
+
+	changes, err := c.Modify(func(s *Snapshot) error {
+		*s = next
+		return nil
+	}, false)
+	if err != nil {
+		return changes, err
+	}
+
+	c.fileSum = sum
+	if c.history != nil {
+		if _, err := c.history.Record(data, "file"); err != nil {
+			log.Warn().Err(err).Msg("Failed to record reloaded config in history")
+		}
+	}
+
+	return changes, nil
+}
+
+// Watch reloads the configuration whenever the file changes on disk (checked
+// every interval) or a value arrives on trigger, typically SIGHUP. It returns
+// when ctx is cancelled.
+func (c *Config) Watch(ctx context.Context, interval time.Duration, trigger <-chan os.Signal) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var lastMod time.Time
+	if info, err := os.Stat(c.path); err == nil {
+		lastMod = info.ModTime()
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case sig := <-trigger:
+			log.Info().Str("signal", sig.String()).Str("path", c.path).Msg("Reloading configuration")
+			c.reloadAndLog()
+
+		case <-ticker.C:
+			info, err := os.Stat(c.path)
+			if err != nil || info.ModTime().Equal(lastMod) {
+				continue
+			}
+			lastMod = info.ModTime()
+			c.reloadAndLog()
+		}
+	}
+}
+
+// reloadAndLog reloads the configuration and logs the outcome and diff
+func (c *Config) reloadAndLog() {
+	changes, err := c.Reload()
+	switch {
+	case errors.Is(err, ErrUnchanged):
+		log.Debug().Str("path", c.path).Msg("Config file unchanged, nothing to reload")
+
+	case err != nil:
+		event := log.Error().Err(err).Str("path", c.path)
+		if len(changes) > 0 {
+			event = event.Interface("rejected_changes", changes)
+		}
+		event.Msg("Rejected config reload, keeping running configuration")
+
+	case len(changes) == 0:
+		log.Info().Str("path", c.path).Msg("Config file reloaded, no effective changes")
+
+	default:
+		log.Info().Str("path", c.path).Interface("changes", changes).Msg("Config reloaded")
+	}
+}
@@ -0,0 +1,211 @@
+package config
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Save atomically writes the current configuration back to the file it was
+// loaded from, keeping any comments in that file, and records the result in
+// the version history attributed to user.
+func (c *Config) Save(user string) error {
+	// saveMu serialises writers without blocking readers of the config
+	c.saveMu.Lock()
+	defer c.saveMu.Unlock()
+
+	if c.path == "" {
+		return errors.New("config has no file path")
+	}
+
+	snap := c.Get()
+	previous, err := os.ReadFile(c.path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	data, err := marshalPreservingComments(snap, previous)
+	if err != nil {
+		return err
+	}
+
+	if c.history != nil && len(previous) > 0 {
+		// Keep the hand-written file as the first version before we replace it
+		if err := c.history.RecordInitial(previous); err != nil {
+			return fmt.Errorf("failed to record config history: %w", err)
+		}
+	}
+
+	if err := writeFileAtomic(c.path, data); err != nil {
+		return err
+	}
+	// Remember what we wrote so the file watcher does not reload our own save
+	c.fileSum = sha256.Sum256(data)
+
+	if c.history != nil {
+		if _, err := c.history.Record(data, user); err != nil {
+			return fmt.Errorf("failed to record config history: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Rollback restores a version from the history, applies it live and saves
+// it as a new version attributed to user.
+func (c *Config) Rollback(version int, user string) ([]Change, error) {
+	if c.history == nil {
+		return nil, errors.New("config history is not available")
+	}
+
+	data, err := c.history.Read(version)
+	if err != nil {
+		return nil, err
+	}
+
+	restored, err := parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("version %d is not a valid configuration: %w", version, err)
+	}
+
+	changes, err := c.Modify(func(s *Snapshot) error {
+		*s = restored.snapshot()
+		return nil
+	}, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return changes, c.Save(fmt.Sprintf("%s (rollback to v%d)", user, version))
+}
+
+// marshalPreservingComments encodes snap as YAML, carrying over comments from
+// the previous file contents wherever the same keys still exist.
+func marshalPreservingComments(snap Snapshot, previous []byte) ([]byte, error) {
+	var node yaml.Node
+	if err := node.Encode(snap); err != nil {
+		return nil, err
+	}
+
+	var old yaml.Node
+	if len(previous) > 0 && yaml.Unmarshal(previous, &old) == nil && len(old.Content) > 0 {
+		node.HeadComment = old.HeadComment
+		node.FootComment = old.FootComment
+		copyComments(old.Content[0], &node)
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&node); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// copyComments copies comments from old onto the matching nodes of node.
+// Mapping entries match by key; sequence items match by their "name" key when
+// present and by position otherwise.
+func copyComments(old, node *yaml.Node) {
+	node.HeadComment = old.HeadComment
+	node.LineComment = old.LineComment
+	node.FootComment = old.FootComment
+
+	if old.Kind != node.Kind {
+		return
+	}
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i].Value
+			for j := 0; j+1 < len(old.Content); j += 2 {
+				if old.Content[j].Value == key {
+					copyComments(old.Content[j], node.Content[i])
+					copyComments(old.Content[j+1], node.Content[i+1])
+					break
+				}
+			}
+		}
+
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			if match := matchSequenceItem(old, item, i); match != nil {
+				copyComments(match, item)
+			}
+		}
+	}
+}
+
+// matchSequenceItem finds the item in old that corresponds to item
+func matchSequenceItem(old, item *yaml.Node, index int) *yaml.Node {
+	if name := mappingValue(item, "name"); name != "" {
+		for _, candidate := range old.Content {
+			if mappingValue(candidate, "name") == name {
+				return candidate
+			}
+		}
+		return nil
+	}
+	if index < len(old.Content) {
+		return old.Content[index]
+	}
+	return nil
+}
+
+// mappingValue returns the scalar value of key in a mapping node
+func mappingValue(node *yaml.Node, key string) string {
+	if node.Kind != yaml.MappingNode {
+		return ""
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1].Value
+		}
+	}
+	return ""
+}
+
+// writeFileAtomic writes data to a temp file in the target directory and
+// renames it over path, so readers never see a partially written file.
+func writeFileAtomic(path string, data []byte) error {
+	mode := os.FileMode(0o644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+	defer func() {
+		// No-op once the rename has succeeded
+		_ = os.Remove(tmpName)
+	}()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpName, mode); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpName, path)
+}
@@ -0,0 +1,199 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const commentedConfig = `# Sentry configuration
+server:
+  host: "0.0.0.0"
+  port: 8080 # API port
+
+cameras:
+  # Porch phone
+  - name: "front"
+    url: "http://192.168.1.10:4747/video"
+    enabled: true
+    motion_threshold: 1000
+    recording:
+      path: "%s"
+      format: "mp4"
+`
+
+func writeConfig(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	data := strings.Replace(commentedConfig, "%s", filepath.Join(dir, "recordings"), 1)
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestSavePreservesCommentsAndMode(t *testing.T) {
+	path := writeConfig(t)
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	cfg.Update(func(c *Config) { c.Server.Port = 9090 })
+	if err := cfg.Save("tester"); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"# Sentry configuration", "# API port", "# Porch phone", "port: 9090"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("Expected saved config to contain %q:\n%s", want, data)
+		}
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("Expected mode 0600 to be kept, got %v", info.Mode().Perm())
+	}
+}
+
+func TestHistoryAndRollback(t *testing.T) {
+	path := writeConfig(t)
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	cfg.Update(func(c *Config) { c.Server.Port = 9090 })
+	if err := cfg.Save("alice"); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	versions, err := cfg.History().List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 2 || versions[0].User != "alice" || versions[1].User != "initial" {
+		t.Fatalf("Expected [alice initial] versions, got %+v", versions)
+	}
+
+	changes, err := cfg.Rollback(versions[1].Version, "bob")
+	if err != nil {
+		t.Fatalf("Rollback failed: %v", err)
+	}
+	if len(changes) != 1 || changes[0].Field != "server.port" {
+		t.Errorf("Expected server.port change, got %+v", changes)
+	}
+	if cfg.Get().Server.Port != 8080 {
+		t.Errorf("Expected port 8080 after rollback, got %d", cfg.Get().Server.Port)
+	}
+}
+
+func TestHistoryIsBounded(t *testing.T) {
+	h := NewHistory(t.TempDir(), 3)
+	for i := 0; i < 5; i++ {
+		if _, err := h.Record([]byte("v"), "tester"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	versions, err := h.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 3 || versions[0].Version != 5 || versions[2].Version != 3 {
+		t.Fatalf("Expected versions 5..3, got %+v", versions)
+	}
+	if _, err := h.Read(1); err == nil {
+		t.Error("Expected pruned version to be gone")
+	}
+}
+
+func TestReloadRejectsInvalidFile(t *testing.T) {
+	path := writeConfig(t)
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	broken := strings.Replace(string(data), "port: 8080", "port: 70000", 1)
+	if err := os.WriteFile(path, []byte(broken), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	changes, err := cfg.Reload()
+	if err == nil {
+		t.Fatal("Expected invalid config to be rejected")
+	}
+	if len(changes) != 1 || changes[0].Field != "server.port" {
+		t.Errorf("Expected rejected server.port change, got %+v", changes)
+	}
+	if cfg.Get().Server.Port != 8080 {
+		t.Errorf("Running config changed to port %d", cfg.Get().Server.Port)
+	}
+
+	fixed := strings.Replace(string(data), "port: 8080", "port: 8081", 1)
+	if err := os.WriteFile(path, []byte(fixed), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cfg.Reload(); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+	if cfg.Get().Server.Port != 8081 {
+		t.Errorf("Expected port 8081 after reload, got %d", cfg.Get().Server.Port)
+	}
+}
+
+func TestLoadWithoutPortUsesDefault(t *testing.T) {
+	path := writeConfig(t)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(strings.Replace(string(data), "  port: 8080 # API port\n", "", 1)), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Expected a config without server.port to load, got %v", err)
+	}
+	if cfg.Get().Server.Port != 8080 {
+		t.Errorf("Expected the default port 8080, got %d", cfg.Get().Server.Port)
+	}
+}
+
+func TestStoragePathIsRelativeToConfig(t *testing.T) {
+	path := writeConfig(t)
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := filepath.Dir(path)
+	if got := cfg.StoragePath(cfg.Get().Storage.StateFile); got != filepath.Join(dir, "state.json") {
+		t.Errorf("Expected the default state file next to the config, got %s", got)
+	}
+	if got := cfg.StoragePath(cfg.Get().Storage.HeatmapDir); got != filepath.Join(dir, "heatmaps") {
+		t.Errorf("Expected the default heatmap dir next to the config, got %s", got)
+	}
+	abs := filepath.Join(t.TempDir(), "counters.json")
+	if got := cfg.StoragePath(abs); got != abs {
+		t.Errorf("Expected an absolute path to be kept, got %s", got)
+	}
+	if cfg.Get().Storage.StateFile != "state.json" {
+		t.Errorf("Expected the configured value to stay relative, got %s", cfg.Get().Storage.StateFile)
+	}
+}
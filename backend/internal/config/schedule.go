@@ -0,0 +1,85 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// weekdays maps schedule day names to weekdays
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// TimeWindow is a daily time window. From and To are "HH:MM" local times;
+// a window whose To is before its From runs past midnight, and one whose
+// From equals its To covers the whole day.
+type TimeWindow struct {
+	// Days are "mon" to "sun"; empty means every day
+	Days []string `yaml:"days,omitempty" json:"days,omitempty"`
+	From string   `yaml:"from" json:"from"`
+	To   string   `yaml:"to" json:"to"`
+}
+
+// Contains reports whether t falls inside the window
+func (w TimeWindow) Contains(t time.Time) bool {
+	from, err1 := parseClock(w.From)
+	to, err2 := parseClock(w.To)
+	if err1 != nil || err2 != nil {
+		return false
+	}
+	now := t.Hour()*60 + t.Minute()
+
+	switch {
+	case from == to:
+		return w.onDay(t.Weekday())
+	case from < to:
+		return now >= from && now < to && w.onDay(t.Weekday())
+	case now >= from:
+		return w.onDay(t.Weekday())
+	case now < to:
+		// The part after midnight belongs to the previous day's window
+		return w.onDay((t.Weekday() + 6) % 7)
+	}
+	return false
+}
+
+func (w TimeWindow) onDay(day time.Weekday) bool {
+	if len(w.Days) == 0 {
+		return true
+	}
+	for _, d := range w.Days {
+		if weekdays[strings.ToLower(d)] == day {
+			return true
+		}
+	}
+	return false
+}
+
+func (w TimeWindow) clone() TimeWindow {
+	w.Days = cloneStrings(w.Days)
+	return w
+}
+
+// parseClock parses "HH:MM" into minutes since midnight
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q (use HH:MM)", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+func validateWindow(verr *ValidationError, prefix string, w TimeWindow) {
+	for field, clock := range map[string]string{"from": w.From, "to": w.To} {
+		if _, err := parseClock(clock); err != nil {
+			verr.add(prefix+"."+field, "%v", err)
+		}
+	}
+	for _, day := range w.Days {
+		if _, ok := weekdays[strings.ToLower(day)]; !ok {
+			verr.add(prefix+".days", "unknown day %q (use mon to sun)", day)
+		}
+	}
+}
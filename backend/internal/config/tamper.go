@@ -0,0 +1,55 @@
+package config
+
+// TamperConfig configures tamper detection: the lens being covered or the
+// image going black, the image losing focus, and the camera being turned or
+// knocked away from its reference view. Checks run on every connected
+// camera, whether or not motion detection is on.
+type TamperConfig struct {
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// IntervalMs is the minimum time between checks
+	IntervalMs int `yaml:"interval_ms" json:"interval_ms"`
+	// HoldSeconds is how long a condition must last before it's reported
+	HoldSeconds int `yaml:"hold_seconds" json:"hold_seconds"`
+	// MinStdDev is the grey-level standard deviation (0-255) below which
+	// the image counts as covered or black
+	MinStdDev float64 `yaml:"min_stddev" json:"min_stddev"`
+	// DefocusRatio is the fraction (0-1) of its usual sharpness, measured
+	// as the variance of the Laplacian, below which the image is defocused
+	DefocusRatio float64 `yaml:"defocus_ratio" json:"defocus_ratio"`
+	// MinSimilarity is the correlation (0-1) with the reference frame below
+	// which the camera counts as moved
+	MinSimilarity float64 `yaml:"min_similarity" json:"min_similarity"`
+	// ReferenceMinutes is how often the reference frame is refreshed while
+	// nothing is wrong, so it follows daylight
+	ReferenceMinutes int `yaml:"reference_minutes" json:"reference_minutes"`
+}
+
+// TamperEnabled reports whether tamper detection runs for the camera: its
+// own tamper setting if it has one, otherwise the global one
+func (cam CameraConfig) TamperEnabled(global TamperConfig) bool {
+	if cam.Tamper != nil {
+		return *cam.Tamper
+	}
+	return global.Enabled
+}
+
+func validateTamper(verr *ValidationError, prefix string, t TamperConfig) {
+	if t.IntervalMs < 0 {
+		verr.add(prefix+".interval_ms", "must not be negative")
+	}
+	if t.HoldSeconds < 0 {
+		verr.add(prefix+".hold_seconds", "must not be negative")
+	}
+	if t.MinStdDev < 0 || t.MinStdDev > 255 {
+		verr.add(prefix+".min_stddev", "must be between 0 and 255")
+	}
+	if t.DefocusRatio < 0 || t.DefocusRatio > 1 {
+		verr.add(prefix+".defocus_ratio", "must be between 0 and 1")
+	}
+	if t.MinSimilarity < 0 || t.MinSimilarity > 1 {
+		verr.add(prefix+".min_similarity", "must be between 0 and 1")
+	}
+	if t.ReferenceMinutes < 0 {
+		verr.add(prefix+".reference_minutes", "must not be negative")
+	}
+}
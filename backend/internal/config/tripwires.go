@@ -0,0 +1,113 @@
+package config
+
+import (
+	"fmt"
+	"image"
+	"math"
+)
+
+// Tripwire crossing directions. A crossing is "in" when it goes from the
+// left to the right of the line, looking from its first point to its second.
+const (
+	DirectionIn   = "in"
+	DirectionOut  = "out"
+	DirectionBoth = "both"
+)
+
+// TripwireConfig is a line segment that tracked objects are counted
+// crossing. Points are 0-1 fractions of the frame like zone points.
+type TripwireConfig struct {
+	Name   string        `yaml:"name" json:"name"`
+	Points [2][2]float64 `yaml:"points" json:"points"`
+	// Direction selects which crossings trigger recordings and
+	// notifications: "in", "out" or "both" (default). Both are counted.
+	Direction string `yaml:"direction,omitempty" json:"direction,omitempty"`
+	// Labels limits triggering crossings to objects of these classes, as
+	// reported by object detection
+	Labels []string `yaml:"labels,omitempty" json:"labels,omitempty"`
+	// Record and Notify make triggering crossings start a recording and
+	// send a notification. A camera with any such tripwire no longer
+	// records or notifies on plain motion.
+	Record bool `yaml:"record,omitempty" json:"record,omitempty"`
+	Notify bool `yaml:"notify,omitempty" json:"notify,omitempty"`
+}
+
+// Line returns the tripwire end points in pixels for a frame of the given
+// size
+func (t TripwireConfig) Line(width, height int) (image.Point, image.Point) {
+	pt := func(p [2]float64) image.Point {
+		return image.Pt(int(math.Round(p[0]*float64(width))), int(math.Round(p[1]*float64(height))))
+	}
+	return pt(t.Points[0]), pt(t.Points[1])
+}
+
+// Triggers reports whether a crossing in direction by an object labelled
+// label (empty if unknown) should trigger the tripwire's actions
+func (t TripwireConfig) Triggers(direction, label string) bool {
+	if t.Direction != "" && t.Direction != DirectionBoth && t.Direction != direction {
+		return false
+	}
+	return len(t.Labels) == 0 || contains(t.Labels, label)
+}
+
+func (t TripwireConfig) clone() TripwireConfig {
+	t.Labels = cloneStrings(t.Labels)
+	return t
+}
+
+// Tripwire returns the camera's tripwire with the given name
+func (cam CameraConfig) Tripwire(name string) (TripwireConfig, bool) {
+	for _, t := range cam.Tripwires {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return TripwireConfig{}, false
+}
+
+// TripwireRecording reports whether recordings are started by tripwire
+// crossings instead of motion
+func (cam CameraConfig) TripwireRecording() bool {
+	for _, t := range cam.Tripwires {
+		if t.Record {
+			return true
+		}
+	}
+	return false
+}
+
+// TripwireNotifications reports whether notifications are sent for
+// tripwire crossings instead of motion
+func (cam CameraConfig) TripwireNotifications() bool {
+	for _, t := range cam.Tripwires {
+		if t.Notify {
+			return true
+		}
+	}
+	return false
+}
+
+func validateTripwires(verr *ValidationError, prefix string, tripwires []TripwireConfig) {
+	names := make(map[string]bool, len(tripwires))
+	for i, t := range tripwires {
+		tprefix := fmt.Sprintf("%s.tripwires[%d]", prefix, i)
+		if t.Name == "" {
+			verr.add(tprefix+".name", "is required")
+		} else if names[t.Name] {
+			verr.add(tprefix+".name", "duplicate tripwire name %q", t.Name)
+		}
+		names[t.Name] = true
+
+		for j, p := range t.Points {
+			if p[0] < 0 || p[0] > 1 || p[1] < 0 || p[1] > 1 {
+				verr.add(fmt.Sprintf("%s.points[%d]", tprefix, j), "coordinates must be between 0 and 1")
+			}
+		}
+		if t.Points[0] == t.Points[1] {
+			verr.add(tprefix+".points", "end points must differ")
+		}
+		if t.Direction != "" && t.Direction != DirectionIn && t.Direction != DirectionOut && t.Direction != DirectionBoth {
+			verr.add(tprefix+".direction", "must be %q, %q or %q", DirectionIn, DirectionOut, DirectionBoth)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package config
+
+import (
+	"image"
+	"testing"
+)
+
+func TestTripwireLine(t *testing.T) {
+	tw := TripwireConfig{Name: "gate", Points: [2][2]float64{{0.25, 0.5}, {0.75, 0.5}}}
+	a, b := tw.Line(640, 480)
+	if a != image.Pt(160, 240) || b != image.Pt(480, 240) {
+		t.Errorf("Expected (160,240)-(480,240), got %v-%v", a, b)
+	}
+}
+
+func TestTripwireTriggers(t *testing.T) {
+	tw := TripwireConfig{Name: "gate", Direction: DirectionIn}
+	if !tw.Triggers(DirectionIn, "") {
+		t.Error("Expected an inward crossing to trigger")
+	}
+	if tw.Triggers(DirectionOut, "") {
+		t.Error("Expected an outward crossing not to trigger")
+	}
+
+	tw.Labels = []string{"person"}
+	if tw.Triggers(DirectionIn, "car") || tw.Triggers(DirectionIn, "") {
+		t.Error("Expected only listed labels to trigger")
+	}
+	if !tw.Triggers(DirectionIn, "person") {
+		t.Error("Expected a person crossing inward to trigger")
+	}
+
+	both := TripwireConfig{Name: "road"}
+	if !both.Triggers(DirectionIn, "") || !both.Triggers(DirectionOut, "") {
+		t.Error("Expected both directions to trigger by default")
+	}
+}
@@ -0,0 +1,453 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// FieldError describes a problem with a single configuration field.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError collects every field error found in a configuration.
+type ValidationError struct {
+	Errors []FieldError `json:"errors"`
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, fe := range e.Errors {
+		msgs[i] = fe.Field + ": " + fe.Message
+	}
+	return "invalid configuration: " + strings.Join(msgs, "; ")
+}
+
+// add records a field error
+func (e *ValidationError) add(field, format string, args ...interface{}) {
+	e.Errors = append(e.Errors, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// err returns e if any errors were recorded, otherwise nil
+func (e *ValidationError) err() error {
+	if len(e.Errors) == 0 {
+		return nil
+	}
+	return e
+}
+
+// Change describes a single field that differs between two configurations.
+type Change struct {
+	Field string      `json:"field"`
+	Old   interface{} `json:"old"`
+	New   interface{} `json:"new"`
+}
+
+// supportedFormats lists the recording formats the recorder can produce
+var supportedFormats = map[string]bool{"": true, "mp4": true, "avi": true}
+
+// Validate checks the snapshot and returns a *ValidationError listing every
+// invalid field, or nil.
+func (s Snapshot) Validate() error {
+	verr := &ValidationError{}
+
+	if s.Server.Port < 1 || s.Server.Port > 65535 {
+		verr.add("server.port", "must be between 1 and 65535, got %d", s.Server.Port)
+	}
+
+	seen := make(map[string]int, len(s.Cameras))
+	for i, cam := range s.Cameras {
+		prefix := fmt.Sprintf("cameras[%d]", i)
+		if prev, dup := seen[cam.Name]; dup && cam.Name != "" {
+			verr.add(prefix+".name", "duplicate camera name %q (also cameras[%d])", cam.Name, prev)
+		}
+		seen[cam.Name] = i
+		validateCamera(verr, prefix, cam)
+	}
+
+	validateMotion(verr, "motion", s.Motion)
+
+	if s.Health.CheckIntervalSeconds < 0 {
+		verr.add("health.check_interval_seconds", "must not be negative")
+	}
+	if s.Health.TimeoutSeconds < 0 {
+		verr.add("health.timeout_seconds", "must not be negative")
+	}
+	if s.Health.StallSeconds < 0 {
+		verr.add("health.stall_seconds", "must not be negative")
+	}
+	if s.Health.StallMaxDiff < 0 || s.Health.StallMaxDiff > 255 {
+		verr.add("health.stall_max_diff", "must be between 0 and 255")
+	}
+	if s.Health.TelemetryHistory < 0 {
+		verr.add("health.telemetry_history", "must not be negative")
+	}
+	if s.Health.LowBatteryPercent < 0 || s.Health.LowBatteryPercent > 100 {
+		verr.add("health.low_battery_percent", "must be between 0 and 100")
+	}
+	if s.Health.NotChargingMinutes < 0 {
+		verr.add("health.not_charging_minutes", "must not be negative")
+	}
+	if s.Health.MaxTemperature < 0 {
+		verr.add("health.max_temperature", "must not be negative")
+	}
+
+	if s.Storage.MaxRecordingSizeMB < 0 {
+		verr.add("storage.max_recording_size_mb", "must not be negative")
+	}
+	if s.Storage.RetentionDays < 0 {
+		verr.add("storage.retention_days", "must not be negative")
+	}
+	if s.Storage.ConfigHistory < 0 {
+		verr.add("storage.config_history", "must not be negative")
+	}
+	if s.Storage.HeatmapDays < 0 {
+		verr.add("storage.heatmap_days", "must not be negative")
+	}
+
+	validateObjects(verr, "objects", s.Objects)
+	validateFaces(verr, "faces", s.Faces)
+	validateTamper(verr, "tamper", s.Tamper)
+	validateNotifications(verr, "notifications", s.Notifications)
+
+	return verr.err()
+}
+
+// ValidateCamera checks a single camera configuration in isolation
+func ValidateCamera(cam CameraConfig) error {
+	verr := &ValidationError{}
+	validateCamera(verr, "camera", cam)
+	return verr.err()
+}
+
+func validateCamera(verr *ValidationError, prefix string, cam CameraConfig) {
+	switch {
+	case cam.Name == "":
+		verr.add(prefix+".name", "is required")
+	case strings.Contains(cam.Name, "/"):
+		verr.add(prefix+".name", "must not contain '/'")
+	}
+
+	if cam.URL == "" {
+		verr.add(prefix+".url", "is required")
+	} else if u, err := url.Parse(cam.URL); err != nil {
+		verr.add(prefix+".url", "is not a valid URL: %v", err)
+	} else if u.Scheme == "" || u.Host == "" {
+		verr.add(prefix+".url", "must be an absolute URL such as http://phone:4747/video")
+	}
+
+	if cam.MotionThreshold < 0 {
+		verr.add(prefix+".motion_threshold", "must not be negative")
+	}
+	if cam.MotionThresholdPercent < 0 || cam.MotionThresholdPercent > 100 {
+		verr.add(prefix+".motion_threshold_percent", "must be between 0 and 100")
+	}
+	validateMotion(verr, prefix+".motion", cam.Motion)
+	validateCameraObjects(verr, prefix+".objects", cam.Objects)
+	validateTripwires(verr, prefix, cam.Tripwires)
+	validateControl(verr, prefix+".control", cam.Control)
+
+	zoneNames := make(map[string]bool, len(cam.Zones))
+	for i, z := range cam.Zones {
+		zprefix := fmt.Sprintf("%s.zones[%d]", prefix, i)
+		if z.Name != "" && zoneNames[z.Name] {
+			verr.add(zprefix+".name", "duplicate zone name %q", z.Name)
+		}
+		zoneNames[z.Name] = true
+		if len(z.Points) < 3 {
+			verr.add(zprefix+".points", "needs at least 3 points")
+		}
+		validateDwell(verr, zprefix+".dwell", z.Dwell)
+		for j, p := range z.Points {
+			if p[0] < 0 || p[0] > 1 || p[1] < 0 || p[1] > 1 {
+				verr.add(fmt.Sprintf("%s.points[%d]", zprefix, j), "coordinates must be between 0 and 1")
+			}
+		}
+	}
+
+	rec := cam.Recording
+	if rec.Path == "" {
+		verr.add(prefix+".recording.path", "is required")
+	} else if err := checkWritableDir(rec.Path); err != nil {
+		verr.add(prefix+".recording.path", "%v", err)
+	}
+	if !supportedFormats[strings.ToLower(rec.Format)] {
+		verr.add(prefix+".recording.format", "unsupported format %q (use mp4 or avi)", rec.Format)
+	}
+	if rec.PreBufferSeconds < 0 {
+		verr.add(prefix+".recording.pre_buffer_seconds", "must not be negative")
+	}
+	if rec.PostBufferSeconds < 0 {
+		verr.add(prefix+".recording.post_buffer_seconds", "must not be negative")
+	}
+}
+
+// Threshold units for MotionConfig.ThresholdUnit
+const (
+	ThresholdPixels  = "pixels"
+	ThresholdPercent = "percent"
+)
+
+// Motion detection algorithms for MotionConfig.Algorithm
+const (
+	AlgorithmMOG2           = "mog2"
+	AlgorithmKNN            = "knn"
+	AlgorithmRunningAverage = "running_average"
+	AlgorithmBlockDiff      = "block_diff"
+)
+
+// MotionAlgorithms lists the supported motion detection algorithms
+var MotionAlgorithms = []string{AlgorithmMOG2, AlgorithmKNN, AlgorithmRunningAverage, AlgorithmBlockDiff}
+
+func validateMotion(verr *ValidationError, prefix string, m MotionConfig) {
+	if m.DetectionIntervalMs < 0 {
+		verr.add(prefix+".detection_interval_ms", "must not be negative")
+	}
+	if m.MinArea < 0 {
+		verr.add(prefix+".min_area", "must not be negative")
+	}
+	if m.Algorithm != "" && !contains(MotionAlgorithms, m.Algorithm) {
+		verr.add(prefix+".algorithm", "unknown algorithm %q (use one of %s)", m.Algorithm, strings.Join(MotionAlgorithms, ", "))
+	}
+	if m.History < 0 {
+		verr.add(prefix+".history", "must not be negative")
+	}
+	if m.VarThreshold < 0 {
+		verr.add(prefix+".var_threshold", "must not be negative")
+	}
+	if m.KernelSize < 0 {
+		verr.add(prefix+".kernel_size", "must not be negative")
+	}
+	if m.Dist2Threshold < 0 {
+		verr.add(prefix+".dist2_threshold", "must not be negative")
+	}
+	if m.LearningRate < 0 || m.LearningRate > 1 {
+		verr.add(prefix+".learning_rate", "must be between 0 and 1")
+	}
+	if m.DiffThreshold < 0 || m.DiffThreshold > 255 {
+		verr.add(prefix+".diff_threshold", "must be between 0 and 255")
+	}
+	if m.BlockSize < 0 {
+		verr.add(prefix+".block_size", "must not be negative")
+	}
+	if m.TriggerFrames < 0 {
+		verr.add(prefix+".trigger_frames", "must not be negative")
+	}
+	if m.WindowFrames < 0 {
+		verr.add(prefix+".window_frames", "must not be negative")
+	}
+	if m.TriggerFrames > 0 && m.WindowFrames > 0 && m.TriggerFrames > m.WindowFrames {
+		verr.add(prefix+".trigger_frames", "must not exceed window_frames (%d)", m.WindowFrames)
+	}
+	if m.ContinueRatio < 0 || m.ContinueRatio > 1 {
+		verr.add(prefix+".continue_ratio", "must be between 0 and 1")
+	}
+	if m.LightingDelta < 0 || m.LightingDelta > 255 {
+		verr.add(prefix+".lighting_delta", "must be between 0 and 255")
+	}
+	if m.LightingShift < 0 || m.LightingShift > 1 {
+		verr.add(prefix+".lighting_shift", "must be between 0 and 1")
+	}
+	if m.TrackMaxMissed < 0 {
+		verr.add(prefix+".track_max_missed", "must not be negative")
+	}
+	if m.TrackMaxDistance < 0 || m.TrackMaxDistance > 1 {
+		verr.add(prefix+".track_max_distance", "must be between 0 and 1")
+	}
+	if m.StationarySeconds < 0 {
+		verr.add(prefix+".stationary_seconds", "must not be negative")
+	}
+	if m.LongTermRate < 0 || m.LongTermRate > 1 {
+		verr.add(prefix+".long_term_rate", "must be between 0 and 1")
+	}
+	if m.ThresholdUnit != "" && m.ThresholdUnit != ThresholdPixels && m.ThresholdUnit != ThresholdPercent {
+		verr.add(prefix+".threshold_unit", "must be %q or %q", ThresholdPixels, ThresholdPercent)
+	}
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// checkWritableDir verifies that path is (or could be created as) a writable
+// directory without creating it.
+func checkWritableDir(path string) error {
+	dir := filepath.Clean(path)
+	for {
+		info, err := os.Stat(dir)
+		if err == nil {
+			if !info.IsDir() {
+				return fmt.Errorf("%s is not a directory", dir)
+			}
+			f, err := os.CreateTemp(dir, ".write-test-*")
+			if err != nil {
+				return fmt.Errorf("directory %s is not writable", dir)
+			}
+			name := f.Name()
+			_ = f.Close()
+			_ = os.Remove(name)
+			return nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return fmt.Errorf("no existing parent directory for %s", path)
+		}
+		dir = parent
+	}
+}
+
+// DecodeJSON strictly decodes data into v, converting type mismatches and
+// unknown fields into a *ValidationError whose field paths start with prefix.
+func DecodeJSON(data []byte, v interface{}, prefix string) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	err := dec.Decode(v)
+	if err == nil {
+		return nil
+	}
+
+	verr := &ValidationError{}
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &typeErr):
+		verr.add(joinField(prefix, typeErr.Field), "expected %s, got %s", typeErr.Type, typeErr.Value)
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		verr.add(joinField(prefix, field), "unknown field")
+	default:
+		verr.add(prefix, "invalid JSON: %v", err)
+	}
+	return verr
+}
+
+func joinField(prefix, field string) string {
+	switch {
+	case prefix == "":
+		return field
+	case field == "":
+		return prefix
+	default:
+		return prefix + "." + field
+	}
+}
+
+// Diff lists the fields that differ between two snapshots. Cameras are
+// matched by name rather than position.
+func Diff(oldSnap, newSnap Snapshot) []Change {
+	oldFlat := make(map[string]interface{})
+	newFlat := make(map[string]interface{})
+	flatten("", toGeneric(oldSnap), oldFlat)
+	flatten("", toGeneric(newSnap), newFlat)
+
+	changes := make([]Change, 0)
+	for field, oldVal := range oldFlat {
+		newVal, ok := newFlat[field]
+		if !ok {
+			changes = append(changes, Change{Field: field, Old: oldVal})
+			continue
+		}
+		if !reflect.DeepEqual(oldVal, newVal) {
+			changes = append(changes, Change{Field: field, Old: oldVal, New: newVal})
+		}
+	}
+	for field, newVal := range newFlat {
+		if _, ok := oldFlat[field]; !ok {
+			changes = append(changes, Change{Field: field, New: newVal})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes
+}
+
+// toGeneric converts a snapshot into generic JSON values
+func toGeneric(s Snapshot) interface{} {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return nil
+	}
+	var v interface{}
+	_ = json.Unmarshal(data, &v)
+	return v
+}
+
+func flatten(prefix string, v interface{}, out map[string]interface{}) {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, child := range val {
+			flatten(joinField(prefix, k), child, out)
+		}
+	case []interface{}:
+		for i, child := range val {
+			key := fmt.Sprintf("%s[%d]", prefix, i)
+			// Key cameras by name so reordering or removal reads naturally
+			if m, ok := child.(map[string]interface{}); ok && prefix == "cameras" {
+				if name, ok := m["name"].(string); ok {
+					key = fmt.Sprintf("cameras[%s]", name)
+				}
+			}
+			flatten(key, child, out)
+		}
+	default:
+		out[prefix] = val
+	}
+}
+
+// MergeJSON decodes a partial configuration document over the snapshot. Only
+// the global sections may be updated this way; cameras are managed
+// individually. Type mismatches and unknown fields are
+// reported as a *ValidationError.
+func (s *Snapshot) MergeJSON(data []byte) error {
+	var sections map[string]json.RawMessage
+	if err := json.Unmarshal(data, &sections); err != nil {
+		return &ValidationError{Errors: []FieldError{{Message: fmt.Sprintf("invalid JSON: %v", err)}}}
+	}
+
+	targets := map[string]interface{}{
+		"server":        &s.Server,
+		"motion":        &s.Motion,
+		"health":        &s.Health,
+		"storage":       &s.Storage,
+		"objects":       &s.Objects,
+		"faces":         &s.Faces,
+		"tamper":        &s.Tamper,
+		"notifications": &s.Notifications,
+	}
+
+	verr := &ValidationError{}
+	for name, raw := range sections {
+		target, ok := targets[name]
+		switch {
+		case name == "cameras":
+			verr.add(name, "cameras are managed through /api/cameras")
+		case !ok:
+			verr.add(name, "unknown section")
+		default:
+			var fieldErr *ValidationError
+			if err := DecodeJSON(raw, target, name); errors.As(err, &fieldErr) {
+				verr.Errors = append(verr.Errors, fieldErr.Errors...)
+			}
+		}
+	}
+
+	sort.Slice(verr.Errors, func(i, j int) bool { return verr.Errors[i].Field < verr.Errors[j].Field })
+	return verr.err()
+}
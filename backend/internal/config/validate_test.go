@@ -0,0 +1,186 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func validSnapshot(t *testing.T) Snapshot {
+	t.Helper()
+	dir := t.TempDir()
+	return Snapshot{
+		Server: ServerConfig{Host: "0.0.0.0", Port: 8080},
+		Cameras: []CameraConfig{
+			{
+				Name:            "front",
+				URL:             "http://192.168.1.10:4747/video",
+				Enabled:         true,
+				MotionThreshold: 1000,
+				Recording: RecordingConfig{
+					Path:              filepath.Join(dir, "front"),
+					Format:            "mp4",
+					PreBufferSeconds:  5,
+					PostBufferSeconds: 10,
+				},
+			},
+		},
+	}
+}
+
+func fieldErrors(t *testing.T, err error) map[string]bool {
+	t.Helper()
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("Expected *ValidationError, got %v", err)
+	}
+	fields := make(map[string]bool)
+	for _, fe := range verr.Errors {
+		fields[fe.Field] = true
+	}
+	return fields
+}
+
+func TestValidateAcceptsValidConfig(t *testing.T) {
+	if err := validSnapshot(t).Validate(); err != nil {
+		t.Fatalf("Expected valid config, got %v", err)
+	}
+}
+
+func TestValidateReportsFieldPaths(t *testing.T) {
+	snap := validSnapshot(t)
+	snap.Server.Port = 70000
+	dup := snap.Cameras[0]
+	dup.URL = ""
+	dup.Recording.PreBufferSeconds = -1
+	snap.Cameras = append(snap.Cameras, dup)
+
+	fields := fieldErrors(t, snap.Validate())
+	for _, want := range []string{
+		"server.port",
+		"cameras[1].name",
+		"cameras[1].url",
+		"cameras[1].recording.pre_buffer_seconds",
+	} {
+		if !fields[want] {
+			t.Errorf("Expected error for %s, got %v", want, fields)
+		}
+	}
+}
+
+func TestValidateRecordingPathNotDirectory(t *testing.T) {
+	snap := validSnapshot(t)
+	file := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(file, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	snap.Cameras[0].Recording.Path = filepath.Join(file, "sub")
+
+	if fields := fieldErrors(t, snap.Validate()); !fields["cameras[0].recording.path"] {
+		t.Errorf("Expected recording path error, got %v", fields)
+	}
+}
+
+func TestMergeJSONTypeErrors(t *testing.T) {
+	snap := validSnapshot(t)
+	err := snap.MergeJSON([]byte(`{"motion": {"min_area": "big"}, "storage": {"bogus": 1}, "cameras": []}`))
+
+	fields := fieldErrors(t, err)
+	for _, want := range []string{"motion.min_area", "storage.bogus", "cameras"} {
+		if !fields[want] {
+			t.Errorf("Expected error for %s, got %v", want, fields)
+		}
+	}
+}
+
+func TestModifyDryRunReportsChangesWithoutApplying(t *testing.T) {
+	snap := validSnapshot(t)
+	cfg := &Config{}
+	cfg.apply(snap)
+
+	changes, err := cfg.Modify(func(s *Snapshot) error {
+		return s.MergeJSON([]byte(`{"motion": {"min_area": 750}}`))
+	}, true)
+	if err != nil {
+		t.Fatalf("Modify failed: %v", err)
+	}
+
+	if len(changes) != 1 || changes[0].Field != "motion.min_area" {
+		t.Fatalf("Expected single motion.min_area change, got %+v", changes)
+	}
+	if cfg.Get().Motion.MinArea != 0 {
+		t.Error("Dry run must not apply changes")
+	}
+}
+
+func TestCameraEditsDoNotReachLiveConfig(t *testing.T) {
+	snap := validSnapshot(t)
+	on := true
+	snap.Cameras[0].Tamper = &on
+	snap.Cameras[0].Zones = []ZoneConfig{{Name: "porch", Points: [][2]float64{{0, 0}, {0.5, 0}, {0.5, 0.5}}}}
+	cfg := &Config{}
+	cfg.apply(snap)
+
+	for _, dryRun := range []bool{false, true} {
+		// Decode over the copy like the camera PUT handler does
+		cam, err := cfg.Camera("front")
+		if err != nil {
+			t.Fatal(err)
+		}
+		body := `{"tamper": false, "zones": [{"name": "q", "points": [[0, 0]]}]}`
+		if err := json.Unmarshal([]byte(body), &cam); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := cfg.ReplaceCamera("front", cam, dryRun); err == nil {
+			t.Fatal("Expected a zone with one point to be rejected")
+		}
+
+		live := cfg.Get().Cameras[0]
+		if live.Tamper == nil || !*live.Tamper {
+			t.Errorf("Expected tamper to stay on (dry run %v), got %v", dryRun, live.Tamper)
+		}
+		if len(live.Zones) != 1 || live.Zones[0].Name != "porch" {
+			t.Errorf("Expected zones to stay [porch] (dry run %v), got %+v", dryRun, live.Zones)
+		}
+	}
+
+	cam, _ := cfg.Camera("front")
+	*cam.Tamper = false
+	cam.Zones[0].Name = "patio"
+	if _, err := cfg.ReplaceCamera("front", cam, true); err != nil {
+		t.Fatalf("Expected a valid dry run to pass, got %v", err)
+	}
+	live := cfg.Get().Cameras[0]
+	if !*live.Tamper || live.Zones[0].Name != "porch" {
+		t.Errorf("Expected a dry run to leave the camera unchanged, got tamper %v zones %+v", *live.Tamper, live.Zones)
+	}
+}
+
+func TestDiffMatchesCamerasByName(t *testing.T) {
+	oldSnap := validSnapshot(t)
+	newSnap := validSnapshot(t)
+	newSnap.Cameras[0].Recording = oldSnap.Cameras[0].Recording
+	newSnap.Cameras[0].URL = "http://192.168.1.11:4747/video"
+
+	changes := Diff(oldSnap, newSnap)
+	if len(changes) != 1 || changes[0].Field != "cameras[front].url" {
+		t.Fatalf("Expected cameras[front].url change, got %+v", changes)
+	}
+}
+
+func TestEffectiveMotionInheritsDefaults(t *testing.T) {
+	shadows := false
+	snap := validSnapshot(t)
+	snap.Motion = MotionConfig{DetectionIntervalMs: 100, MinArea: 500, Algorithm: "mog2", History: 500}
+	snap.Cameras[0].Motion = MotionConfig{MinArea: 800, DetectShadows: &shadows}
+
+	eff := snap.EffectiveMotion(snap.Cameras[0])
+	if eff.MinArea != 800 || eff.DetectionIntervalMs != 100 || eff.History != 500 {
+		t.Errorf("Unexpected effective motion settings: %+v", eff)
+	}
+	if eff.DetectShadows == nil || *eff.DetectShadows {
+		t.Error("Expected camera detect_shadows override to apply")
+	}
+}
@@ -0,0 +1,96 @@
+package config
+
+import (
+	"image"
+	"math"
+)
+
+// Polygon scales the zone's relative points to pixel coordinates for a
+// frame of the given size
+func (z ZoneConfig) Polygon(width, height int) []image.Point {
+	pts := make([]image.Point, len(z.Points))
+	for i, p := range z.Points {
+		pts[i] = image.Pt(int(math.Round(p[0]*float64(width))), int(math.Round(p[1]*float64(height))))
+	}
+	return pts
+}
+
+// Area returns the zone's area in pixels for a frame of the given size
+func (z ZoneConfig) Area(width, height int) float64 {
+	pts := z.Polygon(width, height)
+	if len(pts) < 3 {
+		return 0
+	}
+
+	// Shoelace formula
+	sum := 0
+	for i := range pts {
+		j := (i + 1) % len(pts)
+		sum += pts[i].X*pts[j].Y - pts[j].X*pts[i].Y
+	}
+	return math.Abs(float64(sum)) / 2
+}
+
+// LimitsMotion reports whether motion is only counted inside the zone; it
+// does unless motion is set to false
+func (z ZoneConfig) LimitsMotion() bool {
+	return z.Motion == nil || *z.Motion
+}
+
+// MotionZones returns the zones that limit where motion is counted
+func MotionZones(zones []ZoneConfig) []ZoneConfig {
+	var motion []ZoneConfig
+	for _, z := range zones {
+		if z.LimitsMotion() {
+			motion = append(motion, z)
+		}
+	}
+	return motion
+}
+
+// ReferenceArea returns the area a percentage threshold is measured against:
+// the total area of the zones that limit motion when there are any,
+// otherwise the whole frame
+func ReferenceArea(zones []ZoneConfig, width, height int) float64 {
+	zones = MotionZones(zones)
+	if len(zones) == 0 {
+		return float64(width * height)
+	}
+
+	total := 0.0
+	for _, z := range zones {
+		total += z.Area(width, height)
+	}
+	return total
+}
+
+// MigrateThreshold converts the camera's absolute pixel threshold into a
+// percentage of the reference area for a stream of the given resolution. It
+// reports false if there is nothing to migrate.
+func (cam *CameraConfig) MigrateThreshold(width, height int) bool {
+	if cam.MotionThresholdPercent > 0 || cam.MotionThreshold <= 0 {
+		return false
+	}
+
+	area := ReferenceArea(cam.Zones, width, height)
+	if area <= 0 {
+		return false
+	}
+
+	// Round to 4 decimals to keep the YAML readable. Contours never cover
+	// more than the whole area, so larger thresholds could never fire anyway.
+	cam.MotionThresholdPercent = math.Min(100, math.Round(cam.MotionThreshold/area*100*1e4)/1e4)
+	return true
+}
+
+func (z ZoneConfig) clone() ZoneConfig {
+	pts := make([][2]float64, len(z.Points))
+	copy(pts, z.Points)
+	z.Points = pts
+	z.Dwell = z.Dwell.clone()
+	if z.Motion != nil {
+		motion := *z.Motion
+		z.Motion = &motion
+	}
+	return z
+}
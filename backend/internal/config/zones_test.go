@@ -0,0 +1,41 @@
+package config
+
+import "testing"
+
+func TestZoneArea(t *testing.T) {
+	z := ZoneConfig{Name: "porch", Points: [][2]float64{{0, 0}, {0.5, 0}, {0.5, 0.5}, {0, 0.5}}}
+	if got := z.Area(640, 480); got != 320*240 {
+		t.Errorf("Expected area %d, got %v", 320*240, got)
+	}
+	if got := ReferenceArea(nil, 640, 480); got != 640*480 {
+		t.Errorf("Expected frame area without zones, got %v", got)
+	}
+
+	off := false
+	dwellOnly := ZoneConfig{Name: "bench", Points: z.Points, Motion: &off}
+	if got := ReferenceArea([]ZoneConfig{dwellOnly}, 640, 480); got != 640*480 {
+		t.Errorf("Expected a zone with motion off not to limit the area, got %v", got)
+	}
+	if got := ReferenceArea([]ZoneConfig{z, dwellOnly}, 640, 480); got != 320*240 {
+		t.Errorf("Expected only the motion zone to count, got %v", got)
+	}
+}
+
+func TestMigrateThreshold(t *testing.T) {
+	cam := CameraConfig{MotionThreshold: 30720}
+	if !cam.MigrateThreshold(640, 480) {
+		t.Fatal("Expected threshold to be migrated")
+	}
+	if cam.MotionThresholdPercent != 10 {
+		t.Errorf("Expected 10%%, got %v", cam.MotionThresholdPercent)
+	}
+	if cam.MigrateThreshold(1920, 1080) {
+		t.Error("Expected an already migrated threshold to be left alone")
+	}
+
+	huge := CameraConfig{MotionThreshold: 500000}
+	huge.MigrateThreshold(640, 480)
+	if huge.MotionThresholdPercent != 100 {
+		t.Errorf("Expected threshold to be capped at 100%%, got %v", huge.MotionThresholdPercent)
+	}
+}
@@ -0,0 +1,153 @@
+// Package counters keeps daily tripwire crossing counts per camera and line.
+package counters
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/kai5263499/droidcam-sentry/backend/internal/config"
+)
+
+// dayFormat keys counts by local calendar day
+const dayFormat = "2006-01-02"
+
+// retention is how long daily counts are kept
+const retention = 366 * 24 * time.Hour
+
+// Count is the number of crossings of one line on one day
+type Count struct {
+	In  int `json:"in"`
+	Out int `json:"out"`
+}
+
+// Store is a JSON-backed store of daily counts, keyed by camera, day and
+// line.
+type Store struct {
+	path    string
+	cameras map[string]map[string]map[string]Count
+	mu      sync.RWMutex
+}
+
+// Load reads the counters file at path. A missing file yields an empty
+// store.
+func Load(path string) (*Store, error) {
+	s := &Store{
+		path:    path,
+		cameras: make(map[string]map[string]map[string]Count),
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &s.cameras); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Add counts one crossing of line in direction at t and writes the store
+// to disk. Days past the retention period are dropped.
+func (s *Store) Add(camera, line, direction string, t time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	days, ok := s.cameras[camera]
+	if !ok {
+		days = make(map[string]map[string]Count)
+		s.cameras[camera] = days
+	}
+	day := t.Local().Format(dayFormat)
+	lines, ok := days[day]
+	if !ok {
+		lines = make(map[string]Count)
+		days[day] = lines
+		s.prune(t)
+	}
+
+	count := lines[line]
+	if direction == config.DirectionIn {
+		count.In++
+	} else {
+		count.Out++
+	}
+	lines[line] = count
+	return s.save()
+}
+
+// Days returns the counts of camera for the days days up to and including
+// the day of now, keyed by date (YYYY-MM-DD) and line. Days without
+// crossings are included empty.
+func (s *Store) Days(camera string, now time.Time, days int) map[string]map[string]Count {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	out := make(map[string]map[string]Count, days)
+	for i := 0; i < days; i++ {
+		day := now.Local().AddDate(0, 0, -i).Format(dayFormat)
+		lines := make(map[string]Count)
+		for line, count := range s.cameras[camera][day] {
+			lines[line] = count
+		}
+		out[day] = lines
+	}
+	return out
+}
+
+// Delete removes the counts of a camera
+func (s *Store) Delete(camera string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.cameras[camera]; !ok {
+		return nil
+	}
+	delete(s.cameras, camera)
+	return s.save()
+}
+
+// prune drops days older than the retention period. Callers must hold the
+// write lock.
+func (s *Store) prune(now time.Time) {
+	cutoff := now.Add(-retention).Local().Format(dayFormat)
+	for _, days := range s.cameras {
+		for day := range days {
+			if day < cutoff {
+				delete(days, day)
+			}
+		}
+	}
+}
+
+// save writes the store to a temp file and renames it into place. Callers
+// must hold the write lock.
+func (s *Store) save() error {
+	if s.path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(s.cameras, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if dir := filepath.Dir(s.path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
@@ -0,0 +1,53 @@
+package counters
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kai5263499/droidcam-sentry/backend/internal/config"
+)
+
+func TestAddAndReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "counters.json")
+	s, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	today := time.Date(2024, 5, 2, 12, 0, 0, 0, time.Local)
+	yesterday := today.AddDate(0, 0, -1)
+	s.Add("driveway", "gate", config.DirectionIn, today)
+	s.Add("driveway", "gate", config.DirectionIn, today)
+	s.Add("driveway", "gate", config.DirectionOut, today)
+	s.Add("driveway", "gate", config.DirectionOut, yesterday)
+
+	reloaded, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	days := reloaded.Days("driveway", today, 3)
+	if len(days) != 3 {
+		t.Fatalf("Expected 3 days, got %d", len(days))
+	}
+	if got := days["2024-05-02"]["gate"]; got != (Count{In: 2, Out: 1}) {
+		t.Errorf("Expected 2 in and 1 out today, got %+v", got)
+	}
+	if got := days["2024-05-01"]["gate"]; got != (Count{Out: 1}) {
+		t.Errorf("Expected 1 out yesterday, got %+v", got)
+	}
+	if len(days["2024-04-30"]) != 0 {
+		t.Errorf("Expected no counts on an empty day, got %+v", days["2024-04-30"])
+	}
+}
+
+func TestPruneOldDays(t *testing.T) {
+	s, _ := Load("")
+	old := time.Date(2023, 1, 1, 12, 0, 0, 0, time.Local)
+	s.Add("driveway", "gate", config.DirectionIn, old)
+	s.Add("driveway", "gate", config.DirectionIn, old.AddDate(2, 0, 0))
+
+	if _, ok := s.cameras["driveway"]["2023-01-01"]; ok {
+		t.Error("Expected days past retention to be dropped")
+	}
+}
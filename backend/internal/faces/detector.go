@@ -0,0 +1,118 @@
+//go:build opencv
+
+package faces
+
+import (
+	"fmt"
+	"image"
+	"sync"
+
+	"github.com/kai5263499/droidcam-sentry/backend/internal/config"
+	"github.com/kai5263499/droidcam-sentry/backend/internal/objects"
+	"gocv.io/x/gocv"
+)
+
+// dnnInputSize is the input size of SSD face models such as res10_300x300
+const dnnInputSize = 300
+
+// ssdLabels are the classes of a face SSD: background and face
+var ssdLabels = []string{"background", "face"}
+
+// Detector finds faces with either a Haar cascade or a DNN face model.
+// Calls are serialised.
+type Detector struct {
+	mu      sync.Mutex
+	cfg     config.FacesConfig
+	cascade gocv.CascadeClassifier
+	net     gocv.Net
+	closed  bool
+}
+
+// New loads the face model described by cfg
+func New(cfg config.FacesConfig) (*Detector, error) {
+	d := &Detector{cfg: cfg}
+
+	if cfg.Type == config.FaceDNN {
+		d.net = gocv.ReadNet(cfg.Model, cfg.ModelConfig)
+		if d.net.Empty() {
+			return nil, fmt.Errorf("failed to load face model %s", cfg.Model)
+		}
+		return d, nil
+	}
+
+	d.cascade = gocv.NewCascadeClassifier()
+	if !d.cascade.Load(cfg.Model) {
+		d.cascade.Close()
+		return nil, fmt.Errorf("failed to load face cascade %s", cfg.Model)
+	}
+	return d, nil
+}
+
+// Config returns the configuration the detector was loaded with
+func (d *Detector) Config() config.FacesConfig {
+	return d.cfg
+}
+
+// Detect returns the bounding boxes of the faces in frame. It finds none
+// once the detector was closed, e.g. by a configuration reload while a
+// camera was still using it.
+func (d *Detector) Detect(frame gocv.Mat) []image.Rectangle {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.closed || frame.Empty() {
+		return nil
+	}
+	if d.cfg.Type == config.FaceDNN {
+		return d.detectDNN(frame)
+	}
+
+	gray := gocv.NewMat()
+	defer gray.Close()
+	gocv.CvtColor(frame, &gray, gocv.ColorBGRToGray)
+	gocv.EqualizeHist(gray, &gray)
+
+	minSize := image.Pt(d.cfg.MinSize, d.cfg.MinSize)
+	return d.cascade.DetectMultiScaleWithParams(gray, 1.1, 4, 0, minSize, image.Pt(0, 0))
+}
+
+func (d *Detector) detectDNN(frame gocv.Mat) []image.Rectangle {
+	size := image.Pt(dnnInputSize, dnnInputSize)
+	blob := gocv.BlobFromImage(frame, 1.0, size, gocv.NewScalar(104, 177, 123, 0), false, false)
+	defer blob.Close()
+
+	d.net.SetInput(blob, "")
+	out := d.net.Forward("")
+	defer out.Close()
+
+	data, err := out.DataPtrFloat32()
+	if err != nil {
+		return nil
+	}
+
+	bounds := image.Rect(0, 0, frame.Cols(), frame.Rows())
+	var boxes []image.Rectangle
+	for _, o := range objects.DecodeSSD(data, ssdLabels, d.cfg.Confidence, frame.Cols(), frame.Rows()) {
+		if o.Label == "face" {
+			if box := o.Box.Intersect(bounds); !box.Empty() {
+				boxes = append(boxes, box)
+			}
+		}
+	}
+	return boxes
+}
+
+// Close releases the model. Detect calls that are running finish first.
+func (d *Detector) Close() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.closed {
+		return
+	}
+	d.closed = true
+	if d.cfg.Type == config.FaceDNN {
+		d.net.Close()
+	} else {
+		d.cascade.Close()
+	}
+}
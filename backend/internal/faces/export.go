@@ -0,0 +1,106 @@
+//go:build opencv
+
+package faces
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"path/filepath"
+	"strings"
+
+	"github.com/kai5263499/droidcam-sentry/backend/internal/recorder"
+	"gocv.io/x/gocv"
+)
+
+const (
+	// exportStride is how often faces are re-detected while exporting; the
+	// previous boxes are reused in between
+	exportStride = 3
+	// exportPadding grows face boxes before pixelating
+	exportPadding = 0.2
+	// defaultExportFPS is used when a file doesn't report its frame rate
+	defaultExportFPS = 30.0
+)
+
+// Pixelate replaces box in img with blocks of its average colour, about
+// blocks across
+func Pixelate(img *gocv.Mat, box image.Rectangle, blocks int) {
+	box = box.Intersect(image.Rect(0, 0, img.Cols(), img.Rows()))
+	if box.Empty() {
+		return
+	}
+
+	roi := img.Region(box)
+	defer roi.Close()
+	small := gocv.NewMat()
+	defer small.Close()
+
+	block := BlockSize(box, blocks)
+	gocv.Resize(roi, &small, image.Pt(max(box.Dx()/block, 1), max(box.Dy()/block, 1)), 0, 0, gocv.InterpolationArea)
+	gocv.Resize(small, &roi, image.Pt(box.Dx(), box.Dy()), 0, 0, gocv.InterpolationNearestNeighbor)
+}
+
+// Export re-renders the recording at src into dir with every detected face
+// pixelated, and returns the path of the MP4 it wrote. It stops with
+// ctx's error once ctx is done.
+func Export(ctx context.Context, src, dir string, det *Detector) (string, error) {
+	video, err := gocv.VideoCaptureFile(src)
+	if err != nil {
+		return "", fmt.Errorf("failed to open %s: %w", src, err)
+	}
+	defer video.Close()
+
+	fps := video.Get(gocv.VideoCaptureFPS)
+	if fps <= 0 {
+		fps = defaultExportFPS
+	}
+
+	name := ExportName(src)
+	aviPath := filepath.Join(dir, strings.TrimSuffix(name, filepath.Ext(name))+".avi")
+	if err := render(ctx, video, aviPath, fps, det); err != nil {
+		return "", err
+	}
+	return recorder.ConvertToMP4Context(ctx, aviPath)
+}
+
+// render writes the frames of video to an MJPEG AVI at path, pixelating
+// faces
+func render(ctx context.Context, video *gocv.VideoCapture, path string, fps float64, det *Detector) error {
+	frame := gocv.NewMat()
+	defer frame.Close()
+
+	var writer *gocv.VideoWriter
+	var boxes []image.Rectangle
+	for n := 0; video.Read(&frame); n++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if frame.Empty() {
+			continue
+		}
+		if writer == nil {
+			var err error
+			writer, err = gocv.VideoWriterFile(path, "MJPG", fps, frame.Cols(), frame.Rows(), true)
+			if err != nil {
+				return fmt.Errorf("failed to open video writer: %w", err)
+			}
+			defer writer.Close()
+		}
+
+		if n%exportStride == 0 {
+			boxes = det.Detect(frame)
+		}
+		bounds := image.Rect(0, 0, frame.Cols(), frame.Rows())
+		for _, box := range boxes {
+			Pixelate(&frame, Expand(box, exportPadding, bounds), det.Config().Blocks)
+		}
+		if err := writer.Write(frame); err != nil {
+			return err
+		}
+	}
+	if writer == nil {
+		return fmt.Errorf("no frames to export")
+	}
+	return nil
+}
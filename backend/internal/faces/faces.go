@@ -0,0 +1,32 @@
+// Package faces finds faces in frames, to mark when they appear in
+// recordings, and pixelates them in exported copies.
+package faces
+
+import (
+	"image"
+	"path/filepath"
+	"strings"
+)
+
+// Expand grows box by frac of its size on every side, clipped to bounds, so
+// pixelation still covers a face that moved slightly between detections
+func Expand(box image.Rectangle, frac float64, bounds image.Rectangle) image.Rectangle {
+	dx, dy := int(float64(box.Dx())*frac), int(float64(box.Dy())*frac)
+	return image.Rect(box.Min.X-dx, box.Min.Y-dy, box.Max.X+dx, box.Max.Y+dy).Intersect(bounds)
+}
+
+// BlockSize returns the side of the pixel blocks used to pixelate box into
+// roughly blocks blocks across
+func BlockSize(box image.Rectangle, blocks int) int {
+	if blocks <= 0 {
+		blocks = 1
+	}
+	size := max(box.Dx(), box.Dy()) / blocks
+	return max(size, 1)
+}
+
+// ExportName returns the file name of the blurred export of a recording
+func ExportName(path string) string {
+	base := filepath.Base(path)
+	return strings.TrimSuffix(base, filepath.Ext(base)) + "_blurred.mp4"
+}
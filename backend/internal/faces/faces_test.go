@@ -0,0 +1,32 @@
+package faces
+
+import (
+	"image"
+	"testing"
+)
+
+func TestExpandClipsToBounds(t *testing.T) {
+	bounds := image.Rect(0, 0, 100, 100)
+	got := Expand(image.Rect(10, 10, 30, 50), 0.25, bounds)
+	if want := image.Rect(5, 0, 35, 60); got != want {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+	if got := Expand(image.Rect(90, 90, 100, 100), 0.5, bounds); got.Max != bounds.Max {
+		t.Errorf("Expected box clipped to the frame, got %v", got)
+	}
+}
+
+func TestBlockSize(t *testing.T) {
+	if got := BlockSize(image.Rect(0, 0, 80, 40), 8); got != 10 {
+		t.Errorf("Expected block size 10, got %d", got)
+	}
+	if got := BlockSize(image.Rect(0, 0, 4, 4), 8); got != 1 {
+		t.Errorf("Expected block size of at least 1, got %d", got)
+	}
+}
+
+func TestExportName(t *testing.T) {
+	if got := ExportName("/rec/cam_20240101_120000.mp4"); got != "cam_20240101_120000_blurred.mp4" {
+		t.Errorf("Expected blurred MP4 name, got %s", got)
+	}
+}
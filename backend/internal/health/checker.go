@@ -18,6 +18,11 @@ type CheckResult struct {
 	URLError      string `json:"url_error,omitempty"`
 	ResponseTime  int64  `json:"response_time_ms"`
 	LastChecked   string `json:"last_checked"`
+	// Stalled is set while the running stream keeps delivering the same
+	// frame, since StalledSince; Stalls counts how often it happened
+	Stalled      bool   `json:"stalled"`
+	StalledSince string `json:"stalled_since,omitempty"`
+	Stalls       int    `json:"stalls"`
 }
 
 // Checker performs health checks on camera endpoints
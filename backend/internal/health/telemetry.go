@@ -0,0 +1,280 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/kai5263499/droidcam-sentry/backend/internal/config"
+)
+
+// batteryPath is DroidCam's battery info endpoint, relative to the stream's
+// host
+const batteryPath = "/v1/phone/battery_info"
+
+// Phone alert kinds
+const (
+	LowBattery  = "low_battery"
+	NotCharging = "not_charging"
+	Overheating = "overheating"
+)
+
+const (
+	// batteryMargin is how far above the low battery threshold the level
+	// must climb to clear the alert, so it doesn't flap
+	batteryMargin = 5
+	// temperatureMargin is how many degrees below the maximum the phone must
+	// cool down to clear the overheating alert
+	temperatureMargin = 2
+)
+
+// alertKinds is the order alerts are checked and reported in
+var alertKinds = []string{LowBattery, NotCharging, Overheating}
+
+// Telemetry is one reading of a phone's battery. Fields the phone didn't
+// report are nil.
+type Telemetry struct {
+	Time time.Time `json:"time"`
+	// Battery is the charge level in percent
+	Battery  *float64 `json:"battery,omitempty"`
+	Charging *bool    `json:"charging,omitempty"`
+	// Temperature is the battery temperature in °C
+	Temperature *float64 `json:"temperature,omitempty"`
+}
+
+// Alert reports a phone condition that started, or one that has cleared
+type Alert struct {
+	Kind    string `json:"kind"`
+	Cleared bool   `json:"cleared,omitempty"`
+	// Since is when the condition was first seen
+	Since time.Time `json:"since"`
+	Time  time.Time `json:"time"`
+	// Value is the reading that tripped the alert and Limit its threshold;
+	// both are zero for not_charging
+	Value float64 `json:"value"`
+	Limit float64 `json:"limit"`
+}
+
+// PhoneStatus is a camera's telemetry as reported in the status
+type PhoneStatus struct {
+	Latest  *Telemetry  `json:"latest,omitempty"`
+	History []Telemetry `json:"history"`
+	// Alerts are the conditions currently active
+	Alerts []string `json:"alerts"`
+	// Error is why the latest poll failed, e.g. an app without the battery
+	// endpoint
+	Error string `json:"error,omitempty"`
+}
+
+type alertState struct {
+	since  time.Time
+	active bool
+}
+
+// Phone keeps one camera's telemetry history and alert state. It is safe
+// for concurrent use.
+type Phone struct {
+	mu         sync.Mutex
+	history    []Telemetry
+	conditions map[string]*alertState
+	err        string
+}
+
+// NewPhone creates an empty telemetry history
+func NewPhone() *Phone {
+	p := &Phone{conditions: make(map[string]*alertState)}
+	for _, kind := range alertKinds {
+		p.conditions[kind] = &alertState{}
+	}
+	return p
+}
+
+// Record adds a reading, keeping the latest cfg.TelemetryHistory, and
+// returns the alerts that started or cleared. A condition the reading has
+// no value for keeps its state.
+func (p *Phone) Record(cfg config.HealthConfig, t Telemetry) []Alert {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.err = ""
+	p.history = append(p.history, t)
+	if n := len(p.history) - cfg.TelemetryHistory; n > 0 && cfg.TelemetryHistory > 0 {
+		p.history = p.history[n:]
+	}
+
+	var alerts []Alert
+	if t.Battery != nil {
+		level, limit := *t.Battery, cfg.LowBatteryPercent
+		if p.conditions[LowBattery].active {
+			limit += batteryMargin
+		}
+		alerts = p.update(t.Time, LowBattery, level < limit, 0, level, cfg.LowBatteryPercent, alerts)
+	}
+	if t.Charging != nil {
+		// A full phone may stop charging while still on its charger
+		full := t.Battery != nil && *t.Battery >= 100
+		hold := time.Duration(cfg.NotChargingMinutes) * time.Minute
+		alerts = p.update(t.Time, NotCharging, !*t.Charging && !full, hold, 0, 0, alerts)
+	}
+	if t.Temperature != nil {
+		temp, limit := *t.Temperature, cfg.MaxTemperature
+		if p.conditions[Overheating].active {
+			limit -= temperatureMargin
+		}
+		alerts = p.update(t.Time, Overheating, temp >= limit, 0, temp, cfg.MaxTemperature, alerts)
+	}
+	return alerts
+}
+
+// update advances one condition and appends its alert if it started after
+// hold or cleared
+func (p *Phone) update(now time.Time, kind string, bad bool, hold time.Duration, value, limit float64, alerts []Alert) []Alert {
+	cond := p.conditions[kind]
+	if !bad {
+		wasActive, since := cond.active, cond.since
+		*cond = alertState{}
+		if wasActive {
+			alerts = append(alerts, Alert{Kind: kind, Cleared: true, Since: since, Time: now, Value: value, Limit: limit})
+		}
+		return alerts
+	}
+
+	if cond.since.IsZero() {
+		cond.since = now
+	}
+	if cond.active || now.Sub(cond.since) < hold {
+		return alerts
+	}
+	cond.active = true
+	return append(alerts, Alert{Kind: kind, Since: cond.since, Time: now, Value: value, Limit: limit})
+}
+
+// Failed records a failed poll; the history and alerts are kept
+func (p *Phone) Failed(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.err = err.Error()
+}
+
+// Status returns a copy of the history, the latest reading and the active
+// alerts
+func (p *Phone) Status() PhoneStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	status := PhoneStatus{
+		History: append([]Telemetry{}, p.history...),
+		Alerts:  []string{},
+		Error:   p.err,
+	}
+	if n := len(p.history); n > 0 {
+		latest := p.history[n-1]
+		status.Latest = &latest
+	}
+	for _, kind := range alertKinds {
+		if p.conditions[kind].active {
+			status.Alerts = append(status.Alerts, kind)
+		}
+	}
+	return status
+}
+
+// Telemetry reads the battery info of the phone serving cameraURL
+func (c *Checker) Telemetry(cameraURL string) (Telemetry, error) {
+	parsedURL, err := url.Parse(cameraURL)
+	if err != nil {
+		return Telemetry{}, fmt.Errorf("invalid URL: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	infoURL := parsedURL.Scheme + "://" + parsedURL.Host + batteryPath
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, infoURL, nil)
+	if err != nil {
+		return Telemetry{}, fmt.Errorf("request creation failed: %w", err)
+	}
+
+	resp, err := (&http.Client{Timeout: c.timeout}).Do(req)
+	if err != nil {
+		return Telemetry{}, fmt.Errorf("battery info request failed: %w", err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return Telemetry{}, fmt.Errorf("battery info: HTTP %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if err != nil {
+		return Telemetry{}, fmt.Errorf("battery info: %w", err)
+	}
+	return ParseTelemetry(body, time.Now())
+}
+
+// ParseTelemetry reads a battery info JSON object. App versions name the
+// fields differently, so the level is taken from "level", "battery" or
+// "percent", the charging state from "charging", "is_charging" or
+// "plugged" (true or a non-zero plug type) or else from "amps" (not
+// negative while on power), and the temperature from "temperature" or
+// "temp". Android reports the temperature as a whole number of tenths of
+// °C, so whole numbers are read as tenths and fractional ones as °C.
+func ParseTelemetry(data []byte, now time.Time) (Telemetry, error) {
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return Telemetry{}, fmt.Errorf("battery info: %w", err)
+	}
+
+	t := Telemetry{Time: now}
+	if level, ok := number(fields, "level", "battery", "percent"); ok {
+		t.Battery = &level
+	}
+	if charging, ok := flag(fields, "charging", "is_charging", "plugged"); ok {
+		t.Charging = &charging
+	} else if amps, ok := number(fields, "amps"); ok {
+		charging := amps >= 0
+		t.Charging = &charging
+	}
+	if temp, ok := number(fields, "temperature", "temp"); ok {
+		if temp == math.Trunc(temp) {
+			temp /= 10
+		}
+		t.Temperature = &temp
+	}
+
+	if t.Battery == nil && t.Charging == nil && t.Temperature == nil {
+		return Telemetry{}, fmt.Errorf("battery info: no battery fields in response")
+	}
+	return t, nil
+}
+
+// number returns the first of keys holding a number
+func number(fields map[string]interface{}, keys ...string) (float64, bool) {
+	for _, key := range keys {
+		if v, ok := fields[key].(float64); ok {
+			return v, true
+		}
+	}
+	return 0, false
+}
+
+// flag returns the first of keys holding a boolean, or a number that is
+// non-zero for true
+func flag(fields map[string]interface{}, keys ...string) (bool, bool) {
+	for _, key := range keys {
+		switch v := fields[key].(type) {
+		case bool:
+			return v, true
+		case float64:
+			return v != 0, true
+		}
+	}
+	return false, false
+}
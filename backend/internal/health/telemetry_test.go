@@ -0,0 +1,144 @@
+package health
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kai5263499/droidcam-sentry/backend/internal/config"
+)
+
+var testHealth = config.HealthConfig{
+	TelemetryHistory:   3,
+	LowBatteryPercent:  20,
+	NotChargingMinutes: 5,
+	MaxTemperature:     45,
+}
+
+func reading(at time.Time, battery float64, charging bool, temp float64) Telemetry {
+	return Telemetry{Time: at, Battery: &battery, Charging: &charging, Temperature: &temp}
+}
+
+func kinds(alerts []Alert) []string {
+	var out []string
+	for _, a := range alerts {
+		k := a.Kind
+		if a.Cleared {
+			k += " cleared"
+		}
+		out = append(out, k)
+	}
+	return out
+}
+
+func TestParseTelemetry(t *testing.T) {
+	now := time.Unix(1000, 0)
+	cases := []struct {
+		body     string
+		battery  float64
+		charging bool
+		temp     float64
+	}{
+		{`{"level": 85, "charging": true, "temperature": 31.5}`, 85, true, 31.5},
+		{`{"battery": 40, "plugged": 0, "temp": 362}`, 40, false, 36.2},
+		{`{"level": 60, "amps": -0.3, "temp": 29.5}`, 60, false, 29.5},
+		// A cold phone, 9.5 °C and 0.5 °C in tenths
+		{`{"level": 70, "charging": true, "temperature": 95}`, 70, true, 9.5},
+		{`{"level": 70, "charging": true, "temperature": 5}`, 70, true, 0.5},
+	}
+	for _, c := range cases {
+		got, err := ParseTelemetry([]byte(c.body), now)
+		if err != nil {
+			t.Fatalf("Expected %s to parse, got %v", c.body, err)
+		}
+		if got.Battery == nil || *got.Battery != c.battery ||
+			got.Charging == nil || *got.Charging != c.charging ||
+			got.Temperature == nil || *got.Temperature != c.temp {
+			t.Errorf("Expected %v %v %v from %s, got %+v", c.battery, c.charging, c.temp, c.body, got)
+		}
+	}
+
+	if _, err := ParseTelemetry([]byte(`{"model": "Pixel"}`), now); err == nil {
+		t.Error("Expected a response without battery fields to be rejected")
+	}
+}
+
+func TestPhoneAlerts(t *testing.T) {
+	p := NewPhone()
+	start := time.Unix(1000, 0)
+	at := func(minutes int) time.Time { return start.Add(time.Duration(minutes) * time.Minute) }
+
+	if alerts := p.Record(testHealth, reading(at(0), 50, true, 30)); len(alerts) != 0 {
+		t.Fatalf("Expected no alerts for a healthy phone, got %v", kinds(alerts))
+	}
+
+	// The charger fails: not_charging waits for the hold time
+	if alerts := p.Record(testHealth, reading(at(1), 49, false, 30)); len(alerts) != 0 {
+		t.Fatalf("Expected not_charging to wait, got %v", kinds(alerts))
+	}
+	alerts := p.Record(testHealth, reading(at(6), 45, false, 30))
+	if got := kinds(alerts); len(got) != 1 || got[0] != NotCharging {
+		t.Fatalf("Expected not_charging after 5 minutes, got %v", got)
+	}
+	if !alerts[0].Since.Equal(at(1)) {
+		t.Errorf("Expected the alert to start when charging stopped, got %v", alerts[0].Since)
+	}
+
+	alerts = p.Record(testHealth, reading(at(7), 19, false, 46))
+	if got := kinds(alerts); len(got) != 2 || got[0] != LowBattery || got[1] != Overheating {
+		t.Fatalf("Expected low_battery and overheating, got %v", got)
+	}
+
+	// Just above the thresholds isn't enough to clear them
+	if alerts := p.Record(testHealth, reading(at(8), 21, true, 44)); len(kinds(alerts)) != 1 {
+		t.Fatalf("Expected only not_charging to clear, got %v", kinds(alerts))
+	}
+	status := p.Status()
+	if len(status.Alerts) != 2 || status.Alerts[0] != LowBattery || status.Alerts[1] != Overheating {
+		t.Errorf("Expected low_battery and overheating to stay active, got %v", status.Alerts)
+	}
+
+	alerts = p.Record(testHealth, reading(at(9), 26, true, 42))
+	if got := kinds(alerts); len(got) != 2 || got[0] != LowBattery+" cleared" || got[1] != Overheating+" cleared" {
+		t.Fatalf("Expected both alerts to clear, got %v", got)
+	}
+}
+
+func TestPhoneFullBatteryAndHistory(t *testing.T) {
+	p := NewPhone()
+	start := time.Unix(1000, 0)
+	for i := 0; i < 10; i++ {
+		at := start.Add(time.Duration(i) * time.Minute)
+		if alerts := p.Record(testHealth, reading(at, 100, false, 30)); len(alerts) != 0 {
+			t.Fatalf("Expected a full phone not to alert, got %v", kinds(alerts))
+		}
+	}
+
+	status := p.Status()
+	if len(status.History) != testHealth.TelemetryHistory {
+		t.Errorf("Expected %d samples, got %d", testHealth.TelemetryHistory, len(status.History))
+	}
+	if status.Latest == nil || !status.Latest.Time.Equal(start.Add(9*time.Minute)) {
+		t.Errorf("Expected the latest sample last, got %+v", status.Latest)
+	}
+}
+
+func TestCheckerTelemetry(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != batteryPath {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"level": 72, "charging": true}`))
+	}))
+	defer srv.Close()
+
+	got, err := NewChecker(time.Second).Telemetry(srv.URL + "/video")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Battery == nil || *got.Battery != 72 || got.Temperature != nil {
+		t.Errorf("Expected a 72%% reading without temperature, got %+v", got)
+	}
+}
@@ -0,0 +1,248 @@
+// Package heatmap accumulates where motion happens in each camera's scene
+// over time, in hourly buckets on a coarse grid.
+package heatmap
+
+import (
+	"compress/gzip"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Grid size of the accumulated heatmap. Masks are downscaled to it, so the
+// persisted size doesn't depend on the stream resolution.
+const (
+	Width  = 160
+	Height = 120
+)
+
+// bucket holds one hour of motion. Cells sum the fraction of each grid
+// cell that was moving over the analysed frames.
+type bucket struct {
+	Hour   time.Time
+	Frames int
+	Cells  []float32
+}
+
+// Heatmap is the motion history of one camera. It is safe for concurrent
+// use.
+type Heatmap struct {
+	mu        sync.Mutex
+	path      string
+	retention time.Duration
+	buckets   []bucket
+	reference []byte
+	dirty     bool
+}
+
+// snapshot is the persisted form of a Heatmap
+type snapshot struct {
+	Buckets   []bucket
+	Reference []byte
+}
+
+// Load reads the heatmap persisted at path, keeping retention worth of
+// hourly buckets. A missing file yields an empty heatmap.
+func Load(path string, retention time.Duration) (*Heatmap, error) {
+	h := &Heatmap{path: path, retention: retention}
+
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return h, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("reading heatmap %s: %w", path, err)
+	}
+	var snap snapshot
+	if err := gob.NewDecoder(zr).Decode(&snap); err != nil {
+		return nil, fmt.Errorf("reading heatmap %s: %w", path, err)
+	}
+
+	h.buckets = snap.Buckets
+	h.reference = snap.Reference
+	return h, nil
+}
+
+// Add accumulates one analysed frame. cells is the foreground mask scaled
+// down to Width x Height, one byte (0-255) per cell.
+func (h *Heatmap) Add(t time.Time, cells []byte) {
+	if len(cells) != Width*Height {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	b := h.bucket(t.Truncate(time.Hour))
+	b.Frames++
+	for i, v := range cells {
+		if v != 0 {
+			b.Cells[i] += float32(v) / 255
+		}
+	}
+	h.dirty = true
+}
+
+// SetReference stores the JPEG snapshot heatmaps are rendered over
+func (h *Heatmap) SetReference(jpeg []byte) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.reference = jpeg
+	h.dirty = true
+}
+
+// Reference returns the JPEG snapshot heatmaps are rendered over, if any
+func (h *Heatmap) Reference() []byte {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.reference
+}
+
+// Sum returns the motion frequency of each cell over the span ending at
+// now, normalised so the busiest cell is 1. Older hours decay, with motion
+// at the start of the span counting half as much as motion now, so recent
+// activity stands out while still showing the whole span.
+func (h *Heatmap) Sum(now time.Time, span time.Duration) []float32 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	out := make([]float32, Width*Height)
+	since := now.Add(-span)
+	for _, b := range h.buckets {
+		if b.Frames == 0 || b.Hour.Add(time.Hour).Before(since) || b.Hour.After(now) {
+			continue
+		}
+
+		age := now.Sub(b.Hour)
+		weight := float32(math.Pow(0.5, age.Hours()/span.Hours()) / float64(b.Frames))
+		for i, v := range b.Cells {
+			out[i] += v * weight
+		}
+	}
+
+	var peak float32
+	for _, v := range out {
+		peak = max(peak, v)
+	}
+	if peak > 0 {
+		for i := range out {
+			out[i] /= peak
+		}
+	}
+	return out
+}
+
+// Save writes the heatmap if it changed since the last save, dropping
+// buckets older than the retention
+func (h *Heatmap) Save(now time.Time) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.prune(now)
+	if !h.dirty || h.path == "" {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(h.path), 0o755); err != nil {
+		return err
+	}
+
+	tmp := h.path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(f)
+	err = gob.NewEncoder(zw).Encode(snapshot{Buckets: h.buckets, Reference: h.reference})
+	if cerr := zw.Close(); err == nil {
+		err = cerr
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, h.path); err != nil {
+		return err
+	}
+
+	h.dirty = false
+	return nil
+}
+
+// Remove deletes the persisted heatmap
+func (h *Heatmap) Remove() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.buckets = nil
+	h.reference = nil
+	h.dirty = false
+	if err := os.Remove(h.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// bucket returns the bucket for hour, creating it if needed. Callers must
+// hold the lock.
+func (h *Heatmap) bucket(hour time.Time) *bucket {
+	for i := len(h.buckets) - 1; i >= 0; i-- {
+		if h.buckets[i].Hour.Equal(hour) {
+			return &h.buckets[i]
+		}
+	}
+	h.buckets = append(h.buckets, bucket{Hour: hour, Cells: make([]float32, Width*Height)})
+	return &h.buckets[len(h.buckets)-1]
+}
+
+// prune drops buckets older than the retention. Callers must hold the lock.
+func (h *Heatmap) prune(now time.Time) {
+	if h.retention <= 0 {
+		return
+	}
+	cutoff := now.Add(-h.retention).Truncate(time.Hour)
+	kept := h.buckets[:0]
+	for _, b := range h.buckets {
+		if !b.Hour.Before(cutoff) {
+			kept = append(kept, b)
+		}
+	}
+	if len(kept) != len(h.buckets) {
+		h.dirty = true
+	}
+	h.buckets = kept
+}
+
+// ParseRange parses a heatmap span such as "24h", "90m" or "7d"
+func ParseRange(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("invalid range %q", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid range %q", s)
+	}
+	return d, nil
+}
@@ -0,0 +1,68 @@
+package heatmap
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestHeatmapSumAndPersist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "front.heatmap")
+	h, err := Load(path, 7*24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)
+	cells := make([]byte, Width*Height)
+	cells[0] = 255
+	cells[1] = 255
+	h.Add(now, cells)
+
+	cells[1] = 0
+	h.Add(now.Add(-3*time.Hour), cells)
+
+	// Motion from two days ago is outside a 24h range
+	old := make([]byte, Width*Height)
+	old[2] = 255
+	h.Add(now.Add(-48*time.Hour), old)
+
+	sum := h.Sum(now, 24*time.Hour)
+	if sum[0] != 1 {
+		t.Errorf("Expected the busiest cell to be 1, got %v", sum[0])
+	}
+	if sum[1] <= 0 || sum[1] >= sum[0] {
+		t.Errorf("Expected a cell with less motion to be between 0 and 1, got %v", sum[1])
+	}
+	if sum[2] != 0 {
+		t.Errorf("Expected motion outside the range to be ignored, got %v", sum[2])
+	}
+
+	h.SetReference([]byte("jpeg"))
+	if err := h.Save(now); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := Load(path, 7*24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := loaded.Sum(now, 24*time.Hour); got[1] != sum[1] {
+		t.Errorf("Expected the loaded heatmap to match, got %v want %v", got[1], sum[1])
+	}
+	if string(loaded.Reference()) != "jpeg" {
+		t.Error("Expected the reference snapshot to be persisted")
+	}
+}
+
+func TestParseRange(t *testing.T) {
+	for in, want := range map[string]time.Duration{"24h": 24 * time.Hour, "7d": 7 * 24 * time.Hour, "90m": 90 * time.Minute} {
+		if got, err := ParseRange(in); err != nil || got != want {
+			t.Errorf("ParseRange(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "d", "-1h", "week"} {
+		if _, err := ParseRange(in); err == nil {
+			t.Errorf("Expected an error for %q", in)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+//go:build opencv
+
+package heatmap
+
+import (
+	"fmt"
+	"image"
+
+	"gocv.io/x/gocv"
+)
+
+// RenderPNG colourises cells (Width x Height values in 0-1, as returned by
+// Sum) and blends them over the JPEG reference snapshot. Without a
+// reference the heatmap is rendered on its own at 640x480.
+func RenderPNG(cells []float32, reference []byte) ([]byte, error) {
+	if len(cells) != Width*Height {
+		return nil, fmt.Errorf("expected %d cells, got %d", Width*Height, len(cells))
+	}
+
+	data := make([]byte, len(cells))
+	for i, v := range cells {
+		data[i] = byte(min(max(v, 0), 1) * 255)
+	}
+	heat, err := gocv.NewMatFromBytes(Height, Width, gocv.MatTypeCV8U, data)
+	if err != nil {
+		return nil, err
+	}
+	defer heat.Close()
+
+	base := gocv.NewMat()
+	if len(reference) > 0 {
+		base.Close()
+		if base, err = gocv.IMDecode(reference, gocv.IMReadColor); err != nil {
+			return nil, fmt.Errorf("decoding reference snapshot: %w", err)
+		}
+	}
+	defer base.Close()
+	size := image.Pt(640, 480)
+	if !base.Empty() {
+		size = image.Pt(base.Cols(), base.Rows())
+	}
+
+	scaled := gocv.NewMat()
+	defer scaled.Close()
+	gocv.Resize(heat, &scaled, size, 0, 0, gocv.InterpolationLinear)
+
+	colored := gocv.NewMat()
+	defer colored.Close()
+	gocv.ApplyColorMap(scaled, &colored, gocv.ColormapJet)
+
+	out := colored
+	if !base.Empty() {
+		blended := gocv.NewMat()
+		defer blended.Close()
+		gocv.AddWeighted(base, 0.5, colored, 0.5, 0, &blended)
+		out = blended
+	}
+
+	buf, err := gocv.IMEncode(gocv.PNGFileExt, out)
+	if err != nil {
+		return nil, err
+	}
+	defer buf.Close()
+	return buf.GetBytes(), nil
+}
@@ -2,15 +2,23 @@
 package logger
 
 import (
+	"io"
 	"os"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 )
 
-// Init initializes the global logger with the specified level
-func Init(level string) {
-	// Use console writer for human-readable output
-	log.Logger = zerolog.New(zerolog.ConsoleWriter{Out: os.Stdout, TimeFormat: "2006/01/02 15:04:05"}).
+// Init initializes the global logger with the specified level and format.
+// Format "json" writes structured JSON lines; anything else uses the
+// human-readable console writer.
+func Init(level, format string) {
+	var out io.Writer = os.Stdout
+	if format != "json" {
+		// Use console writer for human-readable output
+		out = zerolog.ConsoleWriter{Out: os.Stdout, TimeFormat: "2006/01/02 15:04:05"}
+	}
+
+	log.Logger = zerolog.New(out).
 		With().
 		Timestamp().
 		Logger()
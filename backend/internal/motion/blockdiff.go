@@ -0,0 +1,102 @@
+package motion
+
+// BlockDiff is a pure-Go motion detector. It splits a grayscale frame into
+// square blocks and compares each block's mean grey level against a running
+// average of previous frames, which makes it cheap and robust to sensor noise
+// at the cost of coarse masks.
+type BlockDiff struct {
+	BlockSize    int
+	Threshold    int
+	LearningRate float64
+
+	width      int
+	height     int
+	background []float64
+}
+
+// NewBlockDiff creates a block-diff detector
+func NewBlockDiff(blockSize, threshold int, learningRate float64) *BlockDiff {
+	if blockSize <= 0 {
+		blockSize = 16
+	}
+	if learningRate <= 0 || learningRate > 1 {
+		learningRate = 0.05
+	}
+	return &BlockDiff{
+		BlockSize:    blockSize,
+		Threshold:    threshold,
+		LearningRate: learningRate,
+	}
+}
+
+// Apply takes a grayscale image (one byte per pixel, row-major) and returns a
+// mask of the same size in which pixels of changed blocks are 255. The first
+// frame, or the first after a size change, only seeds the background.
+func (b *BlockDiff) Apply(gray []byte, width, height int) []byte {
+	mask := make([]byte, width*height)
+	if width <= 0 || height <= 0 || len(gray) < width*height {
+		return mask
+	}
+
+	cols := (width + b.BlockSize - 1) / b.BlockSize
+	rows := (height + b.BlockSize - 1) / b.BlockSize
+	means := b.blockMeans(gray, width, height, cols, rows)
+
+	if width != b.width || height != b.height || len(b.background) != len(means) {
+		b.width, b.height = width, height
+		b.background = means
+		return mask
+	}
+
+	for by := 0; by < rows; by++ {
+		for bx := 0; bx < cols; bx++ {
+			i := by*cols + bx
+			delta := means[i] - b.background[i]
+			b.background[i] += b.LearningRate * delta
+
+			if delta < 0 {
+				delta = -delta
+			}
+			if delta > float64(b.Threshold) {
+				b.fillBlock(mask, width, height, bx, by)
+			}
+		}
+	}
+
+	return mask
+}
+
+// Reset discards the background so the next frame re-seeds it
+func (b *BlockDiff) Reset() {
+	b.background = nil
+}
+
+func (b *BlockDiff) blockMeans(gray []byte, width, height, cols, rows int) []float64 {
+	sums := make([]float64, cols*rows)
+	counts := make([]int, cols*rows)
+	for y := 0; y < height; y++ {
+		row := gray[y*width : (y+1)*width]
+		by := y / b.BlockSize
+		for x, v := range row {
+			i := by*cols + x/b.BlockSize
+			sums[i] += float64(v)
+			counts[i]++
+		}
+	}
+	for i := range sums {
+		if counts[i] > 0 {
+			sums[i] /= float64(counts[i])
+		}
+	}
+	return sums
+}
+
+func (b *BlockDiff) fillBlock(mask []byte, width, height, bx, by int) {
+	x0, y0 := bx*b.BlockSize, by*b.BlockSize
+	x1, y1 := min(x0+b.BlockSize, width), min(y0+b.BlockSize, height)
+	for y := y0; y < y1; y++ {
+		for x := x0; x < x1; x++ {
+			mask[y*width+x] = 255
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package motion
+
+import "testing"
+
+func TestBlockDiff(t *testing.T) {
+	const w, h = 32, 16
+	frame := make([]byte, w*h)
+	d := NewBlockDiff(8, 20, 0.5)
+
+	if mask := d.Apply(frame, w, h); countSet(mask) != 0 {
+		t.Fatal("Expected the first frame to only seed the background")
+	}
+	if mask := d.Apply(frame, w, h); countSet(mask) != 0 {
+		t.Error("Expected no motion for an unchanged frame")
+	}
+
+	// Brighten a single 8x8 block
+	for y := 8; y < 16; y++ {
+		for x := 16; x < 24; x++ {
+			frame[y*w+x] = 200
+		}
+	}
+	mask := d.Apply(frame, w, h)
+	if got := countSet(mask); got != 64 {
+		t.Errorf("Expected one 8x8 block of motion, got %d pixels", got)
+	}
+	if mask[12*w+20] != 255 || mask[0] != 0 {
+		t.Error("Expected motion only in the changed block")
+	}
+}
+
+func countSet(mask []byte) int {
+	n := 0
+	for _, v := range mask {
+		if v != 0 {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,102 @@
+//go:build opencv
+
+package motion
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+
+	"gocv.io/x/gocv"
+)
+
+// heatRate is how quickly the heat view follows new motion (0-1)
+const heatRate = 0.05
+
+var (
+	debugKeptColor     = color.RGBA{0, 0, 255, 0}
+	debugFilteredColor = color.RGBA{128, 128, 128, 0}
+)
+
+// SetDebug turns retention of the intermediate images for DebugView on or
+// off. It is off unless someone is watching, since it costs a frame copy per
+// analysis.
+func (d *Detector) SetDebug(on bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.debug == on {
+		return
+	}
+	d.debug = on
+	if !on {
+		d.debugMask.Close()
+		d.debugContours.Close()
+		d.heat.Close()
+		d.debugMask = gocv.NewMat()
+		d.debugContours = gocv.NewMat()
+		d.heat = gocv.NewMat()
+	}
+}
+
+// TakeSample returns the outcome of the latest analysis if it hasn't been
+// taken yet, so callers can publish one sample per analysed frame.
+func (d *Detector) TakeSample() (Sample, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if !d.sampled {
+		return Sample{}, false
+	}
+	d.sampled = false
+	return d.sample, true
+}
+
+// DebugView renders one of the debug views of the latest analysis. The
+// caller must close the returned Mat, which is empty until debug is on and a
+// frame has been analysed.
+func (d *Detector) DebugView(view string) gocv.Mat {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	out := gocv.NewMat()
+	switch view {
+	case ViewMask:
+		d.debugMask.CopyTo(&out)
+	case ViewContours:
+		d.debugContours.CopyTo(&out)
+	case ViewHeat:
+		if d.heat.Empty() {
+			break
+		}
+		heat := gocv.NewMat()
+		defer heat.Close()
+		gocv.ConvertScaleAbs(d.heat, &heat, 1, 0)
+		gocv.ApplyColorMap(heat, &out, gocv.ColormapJet)
+	}
+	return out
+}
+
+// updateDebug keeps the dilated, zone-masked foreground, a contour view and
+// the heat accumulation for the debug views
+func (d *Detector) updateDebug(frame, mask gocv.Mat, contours gocv.PointsVector) {
+	mask.CopyTo(&d.debugMask)
+
+	frame.CopyTo(&d.debugContours)
+	for i := 0; i < contours.Size(); i++ {
+		c := debugKeptColor
+		if gocv.ContourArea(contours.At(i)) <= float64(d.MinArea) {
+			c = debugFilteredColor
+		}
+		gocv.DrawContours(&d.debugContours, contours, i, c, 2)
+	}
+	label := fmt.Sprintf("area %d (%.2f%%) threshold %.0f (%.2f%%) filtered %d",
+		d.sample.Area, d.sample.Score, d.sample.ThresholdArea, d.sample.ThresholdScore, d.sample.FilteredArea)
+	gocv.PutText(&d.debugContours, label, image.Pt(10, 20), gocv.FontHersheySimplex, 0.5, overlayTextColor, 1)
+
+	if d.heat.Empty() || d.heat.Cols() != mask.Cols() || d.heat.Rows() != mask.Rows() {
+		mask.ConvertTo(&d.heat, gocv.MatTypeCV32F)
+		return
+	}
+	gocv.AccumulatedWeighted(mask, &d.heat, heatRate)
+}
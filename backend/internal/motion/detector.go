@@ -4,9 +4,14 @@ package motion
 
 import (
 	"image"
+	"image/color"
 	"log"
+	"math"
+	"sync"
 	"time"
 
+	"github.com/kai5263499/droidcam-sentry/backend/internal/config"
+	"github.com/kai5263499/droidcam-sentry/backend/internal/heatmap"
 	"gocv.io/x/gocv"
 )
 
@@ -14,69 +19,380 @@ type Detector struct {
 	Name              string
 	Threshold         float64
 	MinArea           int
-	mog2              gocv.BackgroundSubtractorMOG2
+	Settings          Settings
+	mu                sync.Mutex
+	subtractor        Subtractor
+	kernel            gocv.Mat
+	zoneMask          gocv.Mat
+	zoneSize          image.Point
+	refArea           float64
 	detectionInterval time.Duration
-	lastDetection     time.Time
+	lastAnalysis      time.Time
+	hysteresis        *Hysteresis
+	illumination      *Illumination
+	tracker           *Tracker
+	dwell             *Dwell
+	stationary        *Stationary
+	// longTerm is the background for stationary objects, nil when off
+	longTerm   *longTerm
+	gray       gocv.Mat
+	suppressed int
+
+	// Heatmap, when set, accumulates where motion happens in the frame
+	Heatmap *heatmap.Heatmap
+
+	// regions, tracks and score are the latest analysis, kept for overlays
+	regions []Region
+	tracks  []Track
+	score   float64
+
+	// sample is the latest analysis, taken once by TakeSample
+	sample  Sample
+	sampled bool
+
+	// debug keeps the intermediate images for the debug views
+	debug         bool
+	debugMask     gocv.Mat
+	debugContours gocv.Mat
+	heat          gocv.Mat
 }
 
 type Detection struct {
 	Timestamp time.Time
 	Area      int
-	Frame     gocv.Mat
+	// Score is Area as a percentage of the frame or zone area
+	Score float64
+	Frame gocv.Mat
+	// Regions are the moving blobs larger than MinArea
+	Regions []Region
+	// Tracks are the objects currently followed across frames
+	Tracks []Track
+	// Crossings are the tripwires crossed in this frame
+	Crossings []Crossing
+	// Dwells are the zone dwell rules that fired in this frame
+	Dwells []DwellEvent
+	// Stationary are the objects found to have appeared or been removed
+	Stationary []StationaryEvent
+	// Event is set when this frame started or ended a motion event
+	Event *Event
 }
 
-func NewDetector(name string, threshold float64, minArea int, intervalMs int) *Detector {
-	return &Detector{
+func NewDetector(name string, settings Settings) *Detector {
+	d := &Detector{
 		Name:              name,
-		Threshold:         threshold,
-		MinArea:           minArea,
-		mog2:              gocv.NewBackgroundSubtractorMOG2(),
-		detectionInterval: time.Duration(intervalMs) * time.Millisecond,
+		Threshold:         settings.Threshold,
+		MinArea:           settings.MinArea,
+		Settings:          settings,
+		subtractor:        NewSubtractor(settings),
+		hysteresis:        NewHysteresis(settings.TriggerFrames, settings.WindowFrames),
+		illumination:      NewIllumination(settings.LightingDelta, settings.LightingShift),
+		tracker:           NewTracker(settings.TrackMaxMissed, 0),
+		dwell:             NewDwell(),
+		stationary:        NewStationary(settings.StationaryHold),
+		gray:              gocv.NewMat(),
+		debugMask:         gocv.NewMat(),
+		debugContours:     gocv.NewMat(),
+		heat:              gocv.NewMat(),
+		kernel:            gocv.GetStructuringElement(gocv.MorphRect, image.Pt(settings.KernelSize, settings.KernelSize)),
+		zoneMask:          gocv.NewMat(),
+		detectionInterval: settings.Interval,
+	}
+	if settings.Stationary {
+		d.longTerm = newLongTerm(settings.LongTermRate)
 	}
+	return d
+}
+
+// Configure applies new settings without restarting the camera. The
+// background model is only rebuilt when its algorithm or parameters change,
+// so threshold and zone edits keep the learned background.
+func (d *Detector) Configure(settings Settings) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if !d.Settings.sameModel(settings) {
+		d.subtractor.Close()
+		d.subtractor = NewSubtractor(settings)
+		log.Printf("[%s] Motion algorithm set to %s", d.Name, settings.Algorithm)
+	}
+	if settings.TriggerFrames != d.Settings.TriggerFrames || settings.WindowFrames != d.Settings.WindowFrames {
+		// An event in progress ends with the old window; the recorder's post
+		// buffer covers the gap
+		d.hysteresis = NewHysteresis(settings.TriggerFrames, settings.WindowFrames)
+	}
+	if settings.KernelSize != d.Settings.KernelSize {
+		d.kernel.Close()
+		d.kernel = gocv.GetStructuringElement(gocv.MorphRect, image.Pt(settings.KernelSize, settings.KernelSize))
+	}
+
+	d.tracker.MaxMissed = settings.TrackMaxMissed
+	d.stationary.Hold = settings.StationaryHold
+	switch {
+	case settings.Stationary && d.longTerm == nil:
+		d.longTerm = newLongTerm(settings.LongTermRate)
+	case !settings.Stationary && d.longTerm != nil:
+		d.longTerm.Close()
+		d.longTerm = nil
+		d.stationary.Reset()
+	case d.longTerm != nil:
+		d.longTerm.rate = settings.LongTermRate
+	}
+	d.illumination.Delta = settings.LightingDelta
+	d.illumination.Shift = settings.LightingShift
+	if !settings.SuppressLighting {
+		d.illumination.Reset()
+	}
+
+	d.Threshold = settings.Threshold
+	d.MinArea = settings.MinArea
+	d.detectionInterval = settings.Interval
+	d.Settings = settings
+
+	// Force the zone mask to be rebuilt on the next frame
+	d.zoneSize = image.Point{}
 }
 
 func (d *Detector) Detect(frame gocv.Mat) (*Detection, bool) {
-	now := time.Now()
-	if now.Sub(d.lastDetection) < d.detectionInterval {
-		return nil, false
+	return d.DetectAt(frame, time.Now())
+}
+
+// DetectAt is Detect with the frame's capture time supplied, for replaying
+// recorded video at its own pace
+func (d *Detector) DetectAt(frame gocv.Mat, now time.Time) (*Detection, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if now.Sub(d.lastAnalysis) < d.detectionInterval {
+		return nil, d.hysteresis.Active()
+	}
+
+	d.lastAnalysis = now
+	d.updateZones(frame.Cols(), frame.Rows())
+
+	if d.Settings.SuppressLighting && d.lightingChanged(frame) {
+		d.sample = Sample{Timestamp: now, Active: d.hysteresis.Active(), Suppressed: true}
+		d.sampled = true
+		return nil, d.hysteresis.Active()
 	}
 
 	// Create mask using background subtraction
 	mask := gocv.NewMat()
 	defer mask.Close()
-	d.mog2.Apply(frame, &mask)
+	d.subtractor.Apply(frame, &mask)
+	if mask.Empty() {
+		return nil, d.hysteresis.Active()
+	}
 
 	// Noise reduction
-	kernel := gocv.GetStructuringElement(gocv.MorphRect, image.Pt(5, 5))
-	defer kernel.Close()
-	gocv.Dilate(mask, &mask, kernel)
+	gocv.Dilate(mask, &mask, d.kernel)
+
+	// Stationary objects are whatever differs from the long-term background
+	// but isn't moving
+	var stationary []StationaryEvent
+	if d.longTerm != nil {
+		stationary = d.detectStationary(now, frame, mask)
+	}
+
+	// The heatmap covers the whole frame so it can guide where zones go
+	if d.Heatmap != nil {
+		d.accumulateHeatmap(now, mask)
+	}
+
+	// Only count motion inside the configured zones
+	if !d.zoneMask.Empty() {
+		gocv.BitwiseAnd(mask, d.zoneMask, &mask)
+	}
 
 	// Find contours
 	contours := gocv.FindContours(mask, gocv.RetrievalExternal, gocv.ChainApproxSimple)
+	defer contours.Close()
 
-	totalArea := 0
+	totalArea, filteredArea := 0, 0
+	var regions []Region
 	for i := 0; i < contours.Size(); i++ {
-		area := gocv.ContourArea(contours.At(i))
-		if area > float64(d.MinArea) {
-			totalArea += int(area)
+		contour := contours.At(i)
+		area := gocv.ContourArea(contour)
+		if area <= float64(d.MinArea) {
+			filteredArea += int(area)
+			continue
+		}
+		totalArea += int(area)
+		regions = append(regions, Region{
+			Box:      gocv.BoundingRect(contour),
+			Centroid: Centroid(contour.ToPoints()),
+			Area:     int(area),
+		})
+	}
+
+	// Motion must be sustained over several analysed frames to start an
+	// event, and falls back to a lower threshold to keep it going
+	score := Score(totalArea, d.refArea)
+	tracks := d.tracker.Update(now, regions)
+	d.regions, d.tracks, d.score = regions, tracks, score
+	triggered := float64(totalArea) > d.Settings.ThresholdArea(d.refArea)
+	sustained := float64(totalArea) > d.Settings.ContinueArea(d.refArea)
+
+	event, active := d.hysteresis.Update(now, totalArea, score, triggered, sustained)
+	if event != nil {
+		if event.Type == EventStart {
+			// The event covers the tracks moving now and any that follow
+			d.tracker.Mark()
 		}
+		event.Tracks = d.tracker.Tracks()
+	} else if !active {
+		// Tracks ending between events belong to none
+		d.tracker.Mark()
 	}
 
-	// Motion detected if total area exceeds threshold
-	if totalArea > int(d.Threshold) {
-		d.lastDetection = now
-		log.Printf("[%s] Motion detected! Area: %d pixels", d.Name, totalArea)
+	thresholdArea := d.Settings.ThresholdArea(d.refArea)
+	d.sample = Sample{
+		Timestamp:      now,
+		Area:           totalArea,
+		Score:          score,
+		ThresholdArea:  thresholdArea,
+		ThresholdScore: Score(int(thresholdArea), d.refArea),
+		ContinueScore:  Score(int(d.Settings.ContinueArea(d.refArea)), d.refArea),
+		FilteredArea:   filteredArea,
+		Regions:        len(regions),
+		Triggered:      triggered,
+		Active:         active,
+	}
+	d.sampled = true
+	if d.debug {
+		d.updateDebug(frame, mask, contours)
+	}
+
+	crossings := d.tracker.Crossings()
+	for _, c := range crossings {
+		log.Printf("[%s] Track %d crossed %s (%s)", d.Name, c.TrackID, c.Line, c.Direction)
+	}
+	dwells := d.dwell.Update(now, tracks)
+	for _, dw := range dwells {
+		log.Printf("[%s] Track %d dwelled in %s for %s", d.Name, dw.TrackID, dw.Zone, dw.Duration.Round(time.Second))
+	}
+
+	if event == nil && !active && len(crossings) == 0 && len(dwells) == 0 && len(stationary) == 0 {
+		return nil, false
+	}
+
+	switch {
+	case event != nil && event.Type == EventStart:
+		log.Printf("[%s] Motion started! Area: %d pixels (%.2f%%)", d.Name, totalArea, score)
+	case event != nil && event.Type == EventEnd:
+		log.Printf("[%s] Motion ended after %s, peak area: %d pixels (%.2f%%), %d tracks", d.Name, event.Duration.Round(time.Millisecond), event.PeakArea, event.PeakScore, len(event.Tracks))
+	}
+
+	return &Detection{
+		Timestamp:  now,
+		Area:       totalArea,
+		Score:      score,
+		Frame:      frame.Clone(),
+		Regions:    regions,
+		Tracks:     tracks,
+		Crossings:  crossings,
+		Dwells:     dwells,
+		Stationary: stationary,
+		Event:      event,
+	}, active
+}
+
+// lightingChanged reports whether the frame brightness changed abruptly
+// across the whole frame. The frame's motion is discarded and the background
+// model re-learned from it, since the old background no longer matches.
+func (d *Detector) lightingChanged(frame gocv.Mat) bool {
+	gocv.CvtColor(frame, &d.gray, gocv.ColorBGRToGray)
+	change := d.illumination.Check(d.gray.ToBytes())
+	if change == nil {
+		return false
+	}
+
+	d.suppressed++
+	d.regions, d.score = nil, 0
+	log.Printf("[%s] Lighting change suppressed (brightness %.0f -> %.0f, histogram shift %.2f)",
+		d.Name, change.From, change.To, change.Shift)
+
+	d.subtractor.Close()
+	d.subtractor = NewSubtractor(d.Settings)
+	mask := gocv.NewMat()
+	defer mask.Close()
+	d.subtractor.Apply(frame, &mask)
+	if d.longTerm != nil {
+		d.longTerm.Reset()
+		d.stationary.Reset()
+	}
 
-		return &Detection{
-			Timestamp: now,
-			Area:      totalArea,
-			Frame:     frame.Clone(),
-		}, true
+	return true
+}
+
+// accumulateHeatmap scales the mask down to the heatmap grid and adds it
+func (d *Detector) accumulateHeatmap(now time.Time, mask gocv.Mat) {
+	cells := gocv.NewMat()
+	defer cells.Close()
+	gocv.Resize(mask, &cells, image.Pt(heatmap.Width, heatmap.Height), 0, 0, gocv.InterpolationArea)
+	d.Heatmap.Add(now, cells.ToBytes())
+}
+
+// LabelTrack gives the track that box (an object detection) overlaps the
+// object class label, so its tripwire crossings carry it
+func (d *Detector) LabelTrack(box image.Rectangle, label string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.tracker.Label(box, label)
+}
+
+// Suppressed returns how many frames were discarded as lighting changes
+func (d *Detector) Suppressed() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.suppressed
+}
+
+// updateZones rebuilds the zone mask and reference area when the frame size
+// changes
+func (d *Detector) updateZones(width, height int) {
+	size := image.Pt(width, height)
+	if size == d.zoneSize {
+		return
+	}
+	d.zoneSize = size
+
+	// Tracks in the old frame size can't be matched to the new one
+	d.tracker.Reset()
+	d.tracker.MaxDistance = d.Settings.TrackMaxDistance * math.Hypot(float64(width), float64(height))
+	d.tracker.Lines = LinesFromConfig(d.Settings.Tripwires, width, height)
+	d.dwell.Rules = DwellRulesFromConfig(d.Settings.Zones, width, height)
+	d.refArea = config.ReferenceArea(d.Settings.Zones, width, height)
+
+	d.zoneMask.Close()
+	d.zoneMask = gocv.NewMat()
+	zones := config.MotionZones(d.Settings.Zones)
+	if len(zones) == 0 {
+		return
 	}
 
-	return nil, false
+	polygons := make([][]image.Point, 0, len(zones))
+	for _, z := range zones {
+		polygons = append(polygons, z.Polygon(width, height))
+	}
+	pv := gocv.NewPointsVectorFromPoints(polygons)
+	defer pv.Close()
+
+	d.zoneMask = gocv.NewMatWithSize(height, width, gocv.MatTypeCV8U)
+	gocv.FillPoly(&d.zoneMask, pv, color.RGBA{255, 255, 255, 0})
 }
 
 func (d *Detector) Close() {
-	d.mog2.Close()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.subtractor.Close()
+	d.gray.Close()
+	d.debugMask.Close()
+	d.debugContours.Close()
+	d.heat.Close()
+	d.kernel.Close()
+	d.zoneMask.Close()
+	if d.longTerm != nil {
+		d.longTerm.Close()
+	}
 }
@@ -0,0 +1,127 @@
+package motion
+
+import (
+	"image"
+	"slices"
+	"time"
+
+	"github.com/kai5263499/droidcam-sentry/backend/internal/config"
+)
+
+// DwellEvent fires when a tracked object has stayed inside a zone longer
+// than the zone's dwell threshold
+type DwellEvent struct {
+	Zone      string        `json:"zone"`
+	TrackID   int           `json:"track_id"`
+	Label     string        `json:"label,omitempty"`
+	Since     time.Time     `json:"since"`
+	Time      time.Time     `json:"time"`
+	Duration  time.Duration `json:"duration"`
+	Threshold time.Duration `json:"threshold"`
+	Point     image.Point   `json:"point"`
+}
+
+// DwellRule is a zone's dwell rule with the zone in frame pixels
+type DwellRule struct {
+	Zone    string
+	Polygon []image.Point
+	Config  config.DwellConfig
+}
+
+// DwellRulesFromConfig returns the dwell rules of zones for a frame size
+func DwellRulesFromConfig(zones []config.ZoneConfig, width, height int) []DwellRule {
+	var rules []DwellRule
+	for _, z := range zones {
+		if z.Dwell != nil {
+			rules = append(rules, DwellRule{Zone: z.Name, Polygon: z.Polygon(width, height), Config: *z.Dwell})
+		}
+	}
+	return rules
+}
+
+type dwellKey struct {
+	zone  string
+	track int
+}
+
+// Dwell measures how long tracks stay inside zones. Each track fires a
+// zone's rule at most once per visit.
+type Dwell struct {
+	Rules []DwellRule
+
+	since map[dwellKey]time.Time
+	fired map[dwellKey]bool
+}
+
+// NewDwell creates a dwell monitor without rules
+func NewDwell() *Dwell {
+	return &Dwell{since: make(map[dwellKey]time.Time), fired: make(map[dwellKey]bool)}
+}
+
+// Update checks the active tracks against the rules and returns the rules
+// that fired
+func (d *Dwell) Update(now time.Time, tracks []Track) []DwellEvent {
+	var events []DwellEvent
+	seen := make(map[dwellKey]bool)
+
+	for _, rule := range d.Rules {
+		for _, t := range tracks {
+			if !inPolygon(t.Exit, rule.Polygon) {
+				continue
+			}
+			key := dwellKey{zone: rule.Zone, track: t.ID}
+			seen[key] = true
+			since, ok := d.since[key]
+			if !ok {
+				since = t.LastSeen
+				d.since[key] = since
+			}
+			if d.fired[key] {
+				continue
+			}
+
+			threshold, active := rule.Config.Threshold(now)
+			if !active || now.Sub(since) < threshold {
+				continue
+			}
+			if len(rule.Config.Labels) > 0 && !slices.Contains(rule.Config.Labels, t.Label) {
+				continue
+			}
+			d.fired[key] = true
+			events = append(events, DwellEvent{
+				Zone:      rule.Zone,
+				TrackID:   t.ID,
+				Label:     t.Label,
+				Since:     since,
+				Time:      now,
+				Duration:  now.Sub(since),
+				Threshold: threshold,
+				Point:     t.Exit,
+			})
+		}
+	}
+
+	// Tracks that left a zone (or ended) start a new visit next time
+	for key := range d.since {
+		if !seen[key] {
+			delete(d.since, key)
+			delete(d.fired, key)
+		}
+	}
+	return events
+}
+
+// inPolygon reports whether p lies inside the polygon (even-odd rule)
+func inPolygon(p image.Point, polygon []image.Point) bool {
+	inside := false
+	for i, j := 0, len(polygon)-1; i < len(polygon); j, i = i, i+1 {
+		a, b := polygon[i], polygon[j]
+		if (a.Y > p.Y) != (b.Y > p.Y) {
+			x := float64(b.X-a.X)*float64(p.Y-a.Y)/float64(b.Y-a.Y) + float64(a.X)
+			if float64(p.X) < x {
+				inside = !inside
+			}
+		}
+	}
+	return inside
+}
@@ -0,0 +1,69 @@
+package motion
+
+import (
+	"image"
+	"testing"
+	"time"
+
+	"github.com/kai5263499/droidcam-sentry/backend/internal/config"
+)
+
+func TestInPolygon(t *testing.T) {
+	square := []image.Point{{0, 0}, {100, 0}, {100, 100}, {0, 100}}
+	if !inPolygon(image.Pt(50, 50), square) {
+		t.Error("Expected the centre to be inside")
+	}
+	if inPolygon(image.Pt(150, 50), square) {
+		t.Error("Expected a point to the right to be outside")
+	}
+}
+
+func TestDwellFiresOncePerVisit(t *testing.T) {
+	d := NewDwell()
+	d.Rules = []DwellRule{{
+		Zone:    "back-door",
+		Polygon: []image.Point{{0, 0}, {100, 0}, {100, 100}, {0, 100}},
+		Config:  config.DwellConfig{Seconds: 30},
+	}}
+	start := time.Unix(1000, 0)
+	inside := Track{ID: 7, Exit: image.Pt(50, 50), LastSeen: start}
+	passing := Track{ID: 8, Exit: image.Pt(150, 50), LastSeen: start}
+
+	var fired []DwellEvent
+	for s := 0; s <= 40; s += 5 {
+		now := start.Add(time.Duration(s) * time.Second)
+		inside.LastSeen, passing.LastSeen = now, now
+		fired = append(fired, d.Update(now, []Track{inside, passing})...)
+	}
+
+	if len(fired) != 1 {
+		t.Fatalf("Expected 1 dwell event, got %d", len(fired))
+	}
+	ev := fired[0]
+	if ev.Zone != "back-door" || ev.TrackID != 7 || ev.Duration != 30*time.Second {
+		t.Errorf("Expected track 7 dwelling 30s at back-door, got %+v", ev)
+	}
+
+	// Leaving and coming back starts a new visit
+	d.Update(start.Add(45*time.Second), nil)
+	inside.LastSeen = start.Add(50 * time.Second)
+	if got := d.Update(inside.LastSeen, []Track{inside}); len(got) != 0 {
+		t.Errorf("Expected a new visit to start counting again, got %+v", got)
+	}
+}
+
+func TestDwellLabels(t *testing.T) {
+	d := NewDwell()
+	d.Rules = []DwellRule{{
+		Zone:    "porch",
+		Polygon: []image.Point{{0, 0}, {100, 0}, {100, 100}, {0, 100}},
+		Config:  config.DwellConfig{Seconds: 10, Labels: []string{"person"}},
+	}}
+	start := time.Unix(1000, 0)
+	cat := Track{ID: 1, Label: "cat", Exit: image.Pt(50, 50), LastSeen: start}
+
+	d.Update(start, []Track{cat})
+	if got := d.Update(start.Add(20*time.Second), []Track{cat}); len(got) != 0 {
+		t.Errorf("Expected a cat not to fire a person rule, got %+v", got)
+	}
+}
@@ -0,0 +1,132 @@
+package motion
+
+import "time"
+
+// EventType marks a motion event transition
+type EventType string
+
+const (
+	EventStart EventType = "start"
+	EventEnd   EventType = "end"
+)
+
+// Event describes a motion event transition. Start events carry the area of
+// the confirming frame; end events carry the peak and total duration.
+type Event struct {
+	Type      EventType     `json:"type"`
+	Start     time.Time     `json:"start"`
+	End       time.Time     `json:"end,omitempty"`
+	PeakArea  int           `json:"peak_area"`
+	PeakScore float64       `json:"peak_score"`
+	Duration  time.Duration `json:"duration"`
+	// Tracks are the objects followed so far: those moving when the event
+	// started, or all seen during it once it ends
+	Tracks []Track `json:"tracks,omitempty"`
+}
+
+// Hysteresis confirms motion over several analysed frames. An event starts
+// once TriggerFrames of the last WindowFrames frames exceed the trigger
+// threshold and ends once WindowFrames frames in a row fall below the lower
+// continue threshold, so single noisy frames neither start nor end events.
+type Hysteresis struct {
+	TriggerFrames int
+	WindowFrames  int
+
+	window []bool
+	next   int
+	quiet  int
+	active bool
+	start  time.Time
+	last   time.Time
+	peak   int
+	score  float64
+}
+
+// NewHysteresis creates a filter requiring trigger of the last window frames
+func NewHysteresis(trigger, window int) *Hysteresis {
+	if window <= 0 {
+		window = 1
+	}
+	if trigger <= 0 {
+		trigger = 1
+	}
+	if trigger > window {
+		trigger = window
+	}
+	return &Hysteresis{
+		TriggerFrames: trigger,
+		WindowFrames:  window,
+		window:        make([]bool, window),
+	}
+}
+
+// Update records one analysed frame. triggered reports whether the frame
+// exceeded the trigger threshold and sustained whether it exceeded the
+// continue threshold. It returns a start or end event on transitions and
+// whether an event is in progress after this frame.
+func (h *Hysteresis) Update(now time.Time, area int, score float64, triggered, sustained bool) (*Event, bool) {
+	h.window[h.next] = triggered
+	h.next = (h.next + 1) % len(h.window)
+
+	if !h.active {
+		if h.count() < h.TriggerFrames {
+			return nil, false
+		}
+		h.active = true
+		h.start, h.last = now, now
+		h.peak, h.score = area, score
+		h.quiet = 0
+		return &Event{Type: EventStart, Start: now, PeakArea: area, PeakScore: score}, true
+	}
+
+	if area > h.peak {
+		h.peak, h.score = area, score
+	}
+
+	if sustained || triggered {
+		h.quiet = 0
+		h.last = now
+		return nil, true
+	}
+
+	h.quiet++
+	if h.quiet < h.WindowFrames {
+		return nil, true
+	}
+
+	ev := &Event{
+		Type:      EventEnd,
+		Start:     h.start,
+		End:       h.last,
+		PeakArea:  h.peak,
+		PeakScore: h.score,
+		Duration:  h.last.Sub(h.start),
+	}
+	h.Reset()
+	return ev, false
+}
+
+// Active reports whether an event is in progress
+func (h *Hysteresis) Active() bool {
+	return h.active
+}
+
+// Reset forgets the frame window and any event in progress
+func (h *Hysteresis) Reset() {
+	for i := range h.window {
+		h.window[i] = false
+	}
+	h.next, h.quiet = 0, 0
+	h.active = false
+	h.peak, h.score = 0, 0
+}
+
+func (h *Hysteresis) count() int {
+	n := 0
+	for _, v := range h.window {
+		if v {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,49 @@
+package motion
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHysteresisIgnoresSingleFrames(t *testing.T) {
+	h := NewHysteresis(3, 5)
+	now := time.Now()
+
+	for i, triggered := range []bool{true, false, false, true, false, false, true} {
+		if ev, active := h.Update(now.Add(time.Duration(i)*time.Second), 100, 1, triggered, triggered); ev != nil || active {
+			t.Fatalf("Expected isolated frames not to start an event (frame %d)", i)
+		}
+	}
+}
+
+func TestHysteresisStartAndEnd(t *testing.T) {
+	h := NewHysteresis(2, 3)
+	now := time.Now()
+	at := func(i int) time.Time { return now.Add(time.Duration(i) * time.Second) }
+
+	if ev, _ := h.Update(at(0), 100, 1, true, true); ev != nil {
+		t.Fatal("Expected no event after one frame")
+	}
+	ev, active := h.Update(at(1), 300, 3, true, true)
+	if ev == nil || ev.Type != EventStart || !active {
+		t.Fatalf("Expected a start event, got %+v", ev)
+	}
+
+	// Below trigger but above the continue threshold keeps the event going
+	if ev, active := h.Update(at(2), 500, 5, false, true); ev != nil || !active {
+		t.Fatal("Expected the event to continue")
+	}
+
+	for i := 3; i < 5; i++ {
+		if ev, active := h.Update(at(i), 0, 0, false, false); ev != nil || !active {
+			t.Fatalf("Expected the event to survive quiet frame %d", i)
+		}
+	}
+	ev, active = h.Update(at(5), 0, 0, false, false)
+	if ev == nil || ev.Type != EventEnd || active {
+		t.Fatalf("Expected an end event, got %+v", ev)
+	}
+	if ev.PeakArea != 500 || ev.Duration != time.Second {
+		t.Errorf("Expected peak 500 over 1s, got %d over %v", ev.PeakArea, ev.Duration)
+	}
+}
@@ -0,0 +1,78 @@
+package motion
+
+import "math"
+
+// illuminationBins is the number of grey-level histogram bins compared
+const illuminationBins = 32
+
+// LightingChange describes a sudden frame-wide brightness change
+type LightingChange struct {
+	From  float64
+	To    float64
+	Shift float64
+}
+
+// Illumination tracks frame-wide brightness between analysed frames so that
+// lights switching on, passing clouds, auto-exposure and IR mode changes can
+// be told apart from motion. Local changes move the mean and histogram
+// little; global ones move both.
+type Illumination struct {
+	// Delta is the mean brightness change (grey levels) that counts as a
+	// lighting change
+	Delta float64
+	// Shift is the histogram change (0-1, half the L1 distance between the
+	// normalised histograms) that counts as a lighting change
+	Shift float64
+
+	mean   float64
+	hist   [illuminationBins]float64
+	seeded bool
+}
+
+// NewIllumination creates a lighting change detector
+func NewIllumination(delta, shift float64) *Illumination {
+	return &Illumination{Delta: delta, Shift: shift}
+}
+
+// Check compares a grayscale frame (one byte per pixel) with the previous
+// one and returns the change when it exceeds either limit. The frame always
+// becomes the new baseline.
+func (il *Illumination) Check(gray []byte) *LightingChange {
+	if len(gray) == 0 {
+		return nil
+	}
+
+	var hist [illuminationBins]float64
+	sum := 0.0
+	for _, v := range gray {
+		sum += float64(v)
+		hist[int(v)*illuminationBins/256]++
+	}
+	n := float64(len(gray))
+	mean := sum / n
+	for i := range hist {
+		hist[i] /= n
+	}
+
+	prevMean, prevHist, seeded := il.mean, il.hist, il.seeded
+	il.mean, il.hist, il.seeded = mean, hist, true
+	if !seeded {
+		return nil
+	}
+
+	shift := 0.0
+	for i := range hist {
+		shift += math.Abs(hist[i] - prevHist[i])
+	}
+	shift /= 2
+
+	if (il.Delta > 0 && math.Abs(mean-prevMean) >= il.Delta) || (il.Shift > 0 && shift >= il.Shift) {
+		return &LightingChange{From: prevMean, To: mean, Shift: shift}
+	}
+	return nil
+}
+
+// Reset forgets the baseline
+func (il *Illumination) Reset() {
+	il.seeded = false
+}
@@ -0,0 +1,40 @@
+package motion
+
+import "testing"
+
+func TestIllumination(t *testing.T) {
+	const w, h = 40, 30
+	frame := make([]byte, w*h)
+	for i := range frame {
+		frame[i] = 60
+	}
+	il := NewIllumination(40, 0.5)
+
+	if il.Check(frame) != nil {
+		t.Fatal("Expected the first frame to only set the baseline")
+	}
+
+	// A person walking in changes part of the frame
+	local := append([]byte(nil), frame...)
+	for y := 10; y < 20; y++ {
+		for x := 10; x < 20; x++ {
+			local[y*w+x] = 200
+		}
+	}
+	if change := il.Check(local); change != nil {
+		t.Errorf("Expected a local change not to count as lighting, got %+v", change)
+	}
+
+	// Lights switch on
+	bright := make([]byte, w*h)
+	for i := range bright {
+		bright[i] = 180
+	}
+	change := il.Check(bright)
+	if change == nil {
+		t.Fatal("Expected a global brightness change")
+	}
+	if change.To <= change.From {
+		t.Errorf("Expected brightness to increase, got %+v", change)
+	}
+}
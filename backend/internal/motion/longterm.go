@@ -0,0 +1,180 @@
+//go:build opencv
+
+package motion
+
+import (
+	"image"
+	"log"
+	"time"
+
+	"gocv.io/x/gocv"
+)
+
+const (
+	// longTermThreshold is the grey-level change from the long-term
+	// background that marks a pixel as changed
+	longTermThreshold = 30
+	// stationaryPadding grows the before/after crops by this fraction of
+	// the region's larger side
+	stationaryPadding = 0.25
+)
+
+// longTerm is a slowly learned running-average background. Pixels the
+// short-term model sees moving don't update it, so passers-by never become
+// part of it, while something left behind differs from it until absorbed.
+type longTerm struct {
+	rate       float64
+	blurred    gocv.Mat
+	background gocv.Mat
+	snapshot   gocv.Mat
+	diff       gocv.Mat
+	still      gocv.Mat
+}
+
+func newLongTerm(rate float64) *longTerm {
+	return &longTerm{
+		rate:       rate,
+		blurred:    gocv.NewMat(),
+		background: gocv.NewMat(),
+		snapshot:   gocv.NewMat(),
+		diff:       gocv.NewMat(),
+		still:      gocv.NewMat(),
+	}
+}
+
+// Apply writes to static the pixels of frame that differ from the long-term
+// background without moving in the short-term model. It returns false while
+// the background is first seeded.
+func (l *longTerm) Apply(frame, moving gocv.Mat, static *gocv.Mat) bool {
+	gocv.GaussianBlur(frame, &l.blurred, image.Pt(5, 5), 0, 0, gocv.BorderDefault)
+
+	// Seed the background with the first frame (or after a size change)
+	if l.background.Empty() || l.background.Cols() != l.blurred.Cols() || l.background.Rows() != l.blurred.Rows() {
+		l.blurred.ConvertTo(&l.background, gocv.MatTypeCV32F)
+		return false
+	}
+
+	gocv.ConvertScaleAbs(l.background, &l.snapshot, 1, 0)
+	gocv.AbsDiff(l.blurred, l.snapshot, &l.diff)
+	gocv.CvtColor(l.diff, &l.diff, gocv.ColorBGRToGray)
+	gocv.Threshold(l.diff, static, longTermThreshold, 255, gocv.ThresholdBinary)
+
+	// Moving pixels neither count as stationary nor teach the background
+	gocv.BitwiseNot(moving, &l.still)
+	gocv.BitwiseAnd(*static, l.still, static)
+	gocv.AccumulatedWeightedWithMask(l.blurred, &l.background, l.rate, l.still)
+	return true
+}
+
+// Absorb makes box of the latest frame part of the background, so a change
+// that has been reported becomes the new normal
+func (l *longTerm) Absorb(box image.Rectangle) {
+	box = box.Intersect(image.Rect(0, 0, l.blurred.Cols(), l.blurred.Rows()))
+	if box.Empty() || l.background.Empty() {
+		return
+	}
+	src := l.blurred.Region(box)
+	defer src.Close()
+	dst := l.background.Region(box)
+	defer dst.Close()
+	src.ConvertTo(&dst, gocv.MatTypeCV32F)
+}
+
+// Reset drops the background so it is re-seeded from the next frame
+func (l *longTerm) Reset() {
+	l.background.Close()
+	l.background = gocv.NewMat()
+}
+
+func (l *longTerm) Close() {
+	l.blurred.Close()
+	l.background.Close()
+	l.snapshot.Close()
+	l.diff.Close()
+	l.still.Close()
+}
+
+// detectStationary finds the regions that differ from the long-term
+// background without moving and reports those that stayed put for the hold
+// time
+func (d *Detector) detectStationary(now time.Time, frame, moving gocv.Mat) []StationaryEvent {
+	static := gocv.NewMat()
+	defer static.Close()
+	if !d.longTerm.Apply(frame, moving, &static) {
+		return nil
+	}
+
+	gocv.MorphologyEx(static, &static, gocv.MorphOpen, d.kernel)
+	if !d.zoneMask.Empty() {
+		gocv.BitwiseAnd(static, d.zoneMask, &static)
+	}
+
+	contours := gocv.FindContours(static, gocv.RetrievalExternal, gocv.ChainApproxSimple)
+	defer contours.Close()
+
+	var boxes []image.Rectangle
+	for i := 0; i < contours.Size(); i++ {
+		contour := contours.At(i)
+		if gocv.ContourArea(contour) > float64(d.MinArea) {
+			boxes = append(boxes, gocv.BoundingRect(contour))
+		}
+	}
+
+	events := d.stationary.Update(now, boxes)
+	for i := range events {
+		d.describeStationary(&events[i], frame)
+		d.longTerm.Absorb(events[i].Box)
+		log.Printf("[%s] Stationary region %v: %s after %s", d.Name, events[i].Box, events[i].Type, events[i].Duration.Round(time.Second))
+	}
+	return events
+}
+
+// describeStationary classifies a stationary region, finds its zone and
+// crops it from the background and the frame
+func (d *Detector) describeStationary(ev *StationaryEvent, frame gocv.Mat) {
+	bounds := image.Rect(0, 0, frame.Cols(), frame.Rows())
+	box := ev.Box.Intersect(bounds)
+
+	before := d.longTerm.snapshot.Region(box)
+	after := frame.Region(box)
+	ev.Type = ClassifyChange(edgeCount(after), edgeCount(before))
+	before.Close()
+	after.Close()
+
+	centre := image.Pt((box.Min.X+box.Max.X)/2, (box.Min.Y+box.Max.Y)/2)
+	for _, z := range d.Settings.Zones {
+		if inPolygon(centre, z.Polygon(bounds.Dx(), bounds.Dy())) {
+			ev.Zone = z.Name
+			break
+		}
+	}
+
+	pad := int(float64(max(box.Dx(), box.Dy())) * stationaryPadding)
+	crop := box.Inset(-pad).Intersect(bounds)
+	before = d.longTerm.snapshot.Region(crop)
+	defer before.Close()
+	after = frame.Region(crop)
+	defer after.Close()
+	ev.Before, ev.After = encodeJPEG(before), encodeJPEG(after)
+}
+
+// edgeCount returns the number of edge pixels in img
+func edgeCount(img gocv.Mat) int {
+	gray := gocv.NewMat()
+	defer gray.Close()
+	edges := gocv.NewMat()
+	defer edges.Close()
+	gocv.CvtColor(img, &gray, gocv.ColorBGRToGray)
+	gocv.Canny(gray, &edges, 50, 150)
+	return gocv.CountNonZero(edges)
+}
+
+// encodeJPEG returns img as JPEG, or nil if it can't be encoded
+func encodeJPEG(img gocv.Mat) []byte {
+	buf, err := gocv.IMEncode(".jpg", img)
+	if err != nil {
+		return nil
+	}
+	defer buf.Close()
+	return append([]byte(nil), buf.GetBytes()...)
+}
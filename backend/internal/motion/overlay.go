@@ -0,0 +1,75 @@
+//go:build opencv
+
+package motion
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+
+	"gocv.io/x/gocv"
+)
+
+var (
+	overlayBoxColor   = color.RGBA{0, 0, 255, 0}
+	overlayZoneColor  = color.RGBA{0, 255, 255, 0}
+	overlayTextColor  = color.RGBA{255, 255, 255, 0}
+	overlayTrackColor = color.RGBA{0, 255, 0, 0}
+	overlayLineColor  = color.RGBA{255, 0, 255, 0}
+)
+
+// DrawOverlay draws the zone outlines, the bounding boxes and centroids of
+// the latest analysis and the motion score onto img. Boxes persist between
+// analysed frames so the overlay doesn't flicker.
+func (d *Detector) DrawOverlay(img *gocv.Mat) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if len(d.Settings.Zones) > 0 {
+		polygons := make([][]image.Point, 0, len(d.Settings.Zones))
+		for _, z := range d.Settings.Zones {
+			polygons = append(polygons, z.Polygon(img.Cols(), img.Rows()))
+		}
+		pv := gocv.NewPointsVectorFromPoints(polygons)
+		gocv.Polylines(img, pv, true, overlayZoneColor, 1)
+		pv.Close()
+	}
+
+	// Tripwires, with an arrow from the middle pointing inward
+	for _, t := range d.Settings.Tripwires {
+		a, b := t.Line(img.Cols(), img.Rows())
+		gocv.Line(img, a, b, overlayLineColor, 2)
+		mid := a.Add(b).Div(2)
+		inward := image.Pt(a.Y-b.Y, b.X-a.X)
+		if n := math.Hypot(float64(inward.X), float64(inward.Y)); n > 0 {
+			inward = image.Pt(int(float64(inward.X)*20/n), int(float64(inward.Y)*20/n))
+		}
+		gocv.ArrowedLine(img, mid, mid.Add(inward), overlayLineColor, 2)
+		gocv.PutText(img, t.Name, a.Add(image.Pt(4, -4)), gocv.FontHersheySimplex, 0.5, overlayLineColor, 1)
+	}
+
+	for _, r := range d.regions {
+		gocv.Rectangle(img, r.Box, overlayBoxColor, 2)
+		gocv.Circle(img, r.Centroid, 3, overlayBoxColor, -1)
+	}
+
+	for _, t := range d.tracks {
+		if len(t.Path) > 1 {
+			path := make([]image.Point, len(t.Path))
+			for i, p := range t.Path {
+				path[i] = p.Point
+			}
+			pv := gocv.NewPointsVectorFromPoints([][]image.Point{path})
+			gocv.Polylines(img, pv, false, overlayTrackColor, 1)
+			pv.Close()
+		}
+		gocv.PutText(img, fmt.Sprintf("#%d", t.ID), image.Pt(t.Box.Min.X, t.Box.Min.Y-4), gocv.FontHersheySimplex, 0.5, overlayTrackColor, 1)
+	}
+
+	label := fmt.Sprintf("motion %.2f%% (threshold %.2f%%)", d.score, Score(int(d.Settings.ThresholdArea(d.refArea)), d.refArea))
+	if d.hysteresis.Active() {
+		label += " EVENT"
+	}
+	gocv.PutText(img, label, image.Pt(10, img.Rows()-10), gocv.FontHersheySimplex, 0.5, overlayTextColor, 1)
+}
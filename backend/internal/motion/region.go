@@ -0,0 +1,39 @@
+package motion
+
+import "image"
+
+// Region is one moving blob found in a frame
+type Region struct {
+	Box      image.Rectangle `json:"box"`
+	Centroid image.Point     `json:"centroid"`
+	Area     int             `json:"area"`
+}
+
+// Centroid returns the centre of mass of a closed polygon. Degenerate
+// polygons (lines, single points) fall back to the mean of their points.
+func Centroid(points []image.Point) image.Point {
+	if len(points) == 0 {
+		return image.Point{}
+	}
+
+	var area, cx, cy float64
+	for i, p := range points {
+		q := points[(i+1)%len(points)]
+		cross := float64(p.X*q.Y - q.X*p.Y)
+		area += cross
+		cx += float64(p.X+q.X) * cross
+		cy += float64(p.Y+q.Y) * cross
+	}
+
+	if area == 0 {
+		var sx, sy int
+		for _, p := range points {
+			sx += p.X
+			sy += p.Y
+		}
+		return image.Pt(sx/len(points), sy/len(points))
+	}
+
+	area *= 3
+	return image.Pt(int(cx/area+0.5), int(cy/area+0.5))
+}
@@ -0,0 +1,24 @@
+package motion
+
+import (
+	"image"
+	"testing"
+)
+
+func TestCentroid(t *testing.T) {
+	square := []image.Point{{10, 10}, {30, 10}, {30, 30}, {10, 30}}
+	if got := Centroid(square); got != image.Pt(20, 20) {
+		t.Errorf("Expected (20,20), got %v", got)
+	}
+
+	// An L shape's centroid is pulled towards its larger arm
+	l := []image.Point{{0, 0}, {40, 0}, {40, 10}, {10, 10}, {10, 40}, {0, 40}}
+	if got := Centroid(l); got.X >= 20 || got.Y >= 20 {
+		t.Errorf("Expected centroid near the corner, got %v", got)
+	}
+
+	line := []image.Point{{0, 0}, {10, 0}}
+	if got := Centroid(line); got != image.Pt(5, 0) {
+		t.Errorf("Expected (5,0) for a line, got %v", got)
+	}
+}
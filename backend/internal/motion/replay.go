@@ -0,0 +1,165 @@
+package motion
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// Span is a period of motion within a recording, as offsets from its start
+type Span struct {
+	Start     time.Duration `json:"start"`
+	End       time.Duration `json:"end"`
+	PeakArea  int           `json:"peak_area"`
+	PeakScore float64       `json:"peak_score"`
+}
+
+// Overlaps reports whether two spans share any time
+func (s Span) Overlaps(o Span) bool {
+	return s.Start <= o.End && o.Start <= s.End
+}
+
+// RecordedSpan is the motion the live detector saw in a recording of the
+// given duration: recordings start pre before the event and run post past
+// its last motion.
+func RecordedSpan(duration, pre, post time.Duration) Span {
+	end := duration - post
+	if end < pre {
+		end = pre
+	}
+	return Span{Start: pre, End: end}
+}
+
+// Sources of a replay report's recorded span
+const (
+	// RecordedMetadata spans are read from the recording's metadata sidecar
+	RecordedMetadata = "metadata"
+	// RecordedEstimate spans are guessed from the file length and the
+	// recording buffers
+	RecordedEstimate = "estimate"
+)
+
+// TimelinePoint is the detector's view of one analysed frame of a replay
+type TimelinePoint struct {
+	Frame  int           `json:"frame"`
+	Offset time.Duration `json:"offset"`
+	Sample
+}
+
+// Comparison sets replayed events against the event that produced the
+// recording. Delay is how much later than the recorded event the first
+// matching replayed event started (negative if earlier).
+type Comparison struct {
+	Matched []Span        `json:"matched"`
+	Extra   []Span        `json:"extra"`
+	Missed  bool          `json:"missed"`
+	Delay   time.Duration `json:"delay"`
+}
+
+// Compare matches replayed events against the recorded one
+func Compare(replayed []Span, recorded Span) Comparison {
+	c := Comparison{Matched: []Span{}, Extra: []Span{}}
+	for _, ev := range replayed {
+		if ev.Overlaps(recorded) {
+			c.Matched = append(c.Matched, ev)
+		} else {
+			c.Extra = append(c.Extra, ev)
+		}
+	}
+	c.Missed = len(c.Matched) == 0
+	if !c.Missed {
+		c.Delay = c.Matched[0].Start - recorded.Start
+	}
+	return c
+}
+
+// ReplayReport is the outcome of feeding one recording through a detector
+type ReplayReport struct {
+	File       string          `json:"file"`
+	Camera     string          `json:"camera"`
+	Frames     int             `json:"frames"`
+	FPS        float64         `json:"fps"`
+	Duration   time.Duration   `json:"duration"`
+	Timeline   []TimelinePoint `json:"timeline"`
+	Events     []Span          `json:"events"`
+	Recorded   Span            `json:"recorded"`
+	Comparison Comparison      `json:"comparison"`
+	// RecordedSource tells where Recorded came from: RecordedMetadata or
+	// RecordedEstimate
+	RecordedSource string `json:"recorded_source"`
+}
+
+// SetRecorded sets the recorded event and compares the replayed events
+// with it
+func (r *ReplayReport) SetRecorded(recorded Span, source string) {
+	r.Recorded, r.RecordedSource = recorded, source
+	r.Comparison = Compare(r.Events, recorded)
+}
+
+// WriteTimelineCSV writes the per-frame scores of reports as CSV
+func WriteTimelineCSV(w io.Writer, reports []ReplayReport) error {
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"file", "frame", "offset_s", "area", "score", "threshold_score", "continue_score", "filtered_area", "regions", "triggered", "active", "suppressed"})
+	for _, r := range reports {
+		for _, p := range r.Timeline {
+			_ = cw.Write([]string{
+				r.File,
+				strconv.Itoa(p.Frame),
+				seconds(p.Offset),
+				strconv.Itoa(p.Area),
+				strconv.FormatFloat(p.Score, 'f', 3, 64),
+				strconv.FormatFloat(p.ThresholdScore, 'f', 3, 64),
+				strconv.FormatFloat(p.ContinueScore, 'f', 3, 64),
+				strconv.Itoa(p.FilteredArea),
+				strconv.Itoa(p.Regions),
+				strconv.FormatBool(p.Triggered),
+				strconv.FormatBool(p.Active),
+				strconv.FormatBool(p.Suppressed),
+			})
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteEventsCSV writes the recorded and replayed events of reports as CSV.
+// Replayed events are marked matched or extra; a recorded event no replayed
+// event overlaps is marked missed.
+func WriteEventsCSV(w io.Writer, reports []ReplayReport) error {
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"file", "source", "start_s", "end_s", "peak_area", "peak_score", "result"})
+	for _, r := range reports {
+		result := "detected"
+		if r.Comparison.Missed {
+			result = "missed"
+		}
+		_ = cw.Write(spanRecord(r.File, "recorded", r.Recorded, result))
+		for _, ev := range r.Events {
+			result := "extra"
+			if ev.Overlaps(r.Recorded) {
+				result = "matched"
+			}
+			_ = cw.Write(spanRecord(r.File, "replay", ev, result))
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func spanRecord(file, source string, s Span, result string) []string {
+	return []string{
+		file,
+		source,
+		seconds(s.Start),
+		seconds(s.End),
+		strconv.Itoa(s.PeakArea),
+		strconv.FormatFloat(s.PeakScore, 'f', 3, 64),
+		result,
+	}
+}
+
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
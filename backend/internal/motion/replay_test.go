@@ -0,0 +1,58 @@
+package motion
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCompare(t *testing.T) {
+	recorded := RecordedSpan(30*time.Second, 5*time.Second, 10*time.Second)
+	if recorded.Start != 5*time.Second || recorded.End != 20*time.Second {
+		t.Fatalf("Unexpected recorded span %+v", recorded)
+	}
+
+	replayed := []Span{
+		{Start: 1 * time.Second, End: 2 * time.Second},
+		{Start: 6 * time.Second, End: 12 * time.Second},
+	}
+	c := Compare(replayed, recorded)
+	if c.Missed || len(c.Matched) != 1 || len(c.Extra) != 1 {
+		t.Fatalf("Expected one matched and one extra event, got %+v", c)
+	}
+	if c.Delay != time.Second {
+		t.Errorf("Expected a 1s delay, got %v", c.Delay)
+	}
+
+	if c := Compare(replayed[:1], recorded); !c.Missed {
+		t.Error("Expected the recorded event to be missed")
+	}
+
+	report := ReplayReport{Events: replayed}
+	report.SetRecorded(Span{Start: time.Second, End: 3 * time.Second}, RecordedMetadata)
+	if report.RecordedSource != RecordedMetadata || report.Comparison.Missed || report.Comparison.Delay != 0 {
+		t.Errorf("Expected the metadata span to match the first event, got %+v", report.Comparison)
+	}
+}
+
+func TestWriteEventsCSV(t *testing.T) {
+	recorded := Span{Start: 5 * time.Second, End: 20 * time.Second}
+	events := []Span{{Start: 6 * time.Second, End: 12 * time.Second, PeakArea: 900}}
+	reports := []ReplayReport{{File: "front_20240501_120000.mp4", Events: events, Recorded: recorded, Comparison: Compare(events, recorded)}}
+
+	var buf bytes.Buffer
+	if err := WriteEventsCSV(&buf, reports); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected a header and two events, got %q", buf.String())
+	}
+	if lines[1] != "front_20240501_120000.mp4,recorded,5.000,20.000,0,0.000,detected" {
+		t.Errorf("Unexpected recorded row %q", lines[1])
+	}
+	if !strings.HasSuffix(lines[2], ",matched") {
+		t.Errorf("Expected the replayed event to match, got %q", lines[2])
+	}
+}
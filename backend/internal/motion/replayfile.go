@@ -0,0 +1,108 @@
+//go:build opencv
+
+package motion
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gocv.io/x/gocv"
+)
+
+// defaultReplayFPS is assumed when a file doesn't report its frame rate; the
+// recorder writes at this rate
+const defaultReplayFPS = 30.0
+
+// ReplayFile feeds a recorded video through a new detector with settings,
+// at the video's own frame rate, and compares the events that would have
+// fired with the recorded event, estimated from pre and post, the recording
+// buffers the file was written with.
+func ReplayFile(path, camera string, settings Settings, pre, post time.Duration) (*ReplayReport, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	video, err := gocv.VideoCaptureFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer video.Close()
+
+	fps := video.Get(gocv.VideoCaptureFPS)
+	if fps <= 0 {
+		fps = defaultReplayFPS
+	}
+
+	// Timestamps are approximate wall-clock times: the file was last written
+	// when the recording ended
+	start := info.ModTime()
+	if count := video.Get(gocv.VideoCaptureFrameCount); count > 0 {
+		start = start.Add(-time.Duration(count / fps * float64(time.Second)))
+	}
+
+	detector := NewDetector(filepath.Base(path), settings)
+	defer detector.Close()
+
+	report := &ReplayReport{
+		File:     filepath.Base(path),
+		Camera:   camera,
+		FPS:      fps,
+		Timeline: []TimelinePoint{},
+		Events:   []Span{},
+	}
+
+	frame := gocv.NewMat()
+	defer frame.Close()
+
+	var open *Span
+	for video.Read(&frame) {
+		if frame.Empty() {
+			continue
+		}
+		offset := time.Duration(float64(report.Frames) / fps * float64(time.Second))
+
+		detection, active := detector.DetectAt(frame, start.Add(offset))
+		if detection != nil {
+			detection.Frame.Close()
+			if ev := detection.Event; ev != nil {
+				switch ev.Type {
+				case EventStart:
+					open = &Span{Start: offset}
+				case EventEnd:
+					if open != nil {
+						open.End = ev.End.Sub(start)
+						open.PeakArea, open.PeakScore = ev.PeakArea, ev.PeakScore
+						report.Events = append(report.Events, *open)
+						open = nil
+					}
+				}
+			}
+		}
+
+		if sample, ok := detector.TakeSample(); ok {
+			report.Timeline = append(report.Timeline, TimelinePoint{Frame: report.Frames, Offset: offset, Sample: sample})
+			if open != nil && active {
+				open.End = offset
+				if sample.Area > open.PeakArea {
+					open.PeakArea, open.PeakScore = sample.Area, sample.Score
+				}
+			}
+		}
+
+		report.Frames++
+	}
+
+	report.Duration = time.Duration(float64(report.Frames) / fps * float64(time.Second))
+
+	// An event still going at the end of the file ends with it
+	if open != nil {
+		open.End = report.Duration
+		report.Events = append(report.Events, *open)
+	}
+
+	report.SetRecorded(RecordedSpan(report.Duration, pre, post), RecordedEstimate)
+	return report, nil
+}
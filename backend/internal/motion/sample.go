@@ -0,0 +1,32 @@
+package motion
+
+import "time"
+
+// Debug views of the detector's intermediate images
+const (
+	ViewMask     = "mask"
+	ViewContours = "contours"
+	ViewHeat     = "heat"
+)
+
+// DebugViews lists the supported debug views
+var DebugViews = []string{ViewMask, ViewContours, ViewHeat}
+
+// Sample is the outcome of analysing one frame, published so thresholds can
+// be tuned from real data. Areas are in pixels and scores in percent of the
+// reference area.
+type Sample struct {
+	Timestamp      time.Time `json:"timestamp"`
+	Area           int       `json:"area"`
+	Score          float64   `json:"score"`
+	ThresholdArea  float64   `json:"threshold_area"`
+	ThresholdScore float64   `json:"threshold_score"`
+	ContinueScore  float64   `json:"continue_score"`
+	// FilteredArea is the area of contours discarded by min_area
+	FilteredArea int  `json:"filtered_area"`
+	Regions      int  `json:"regions"`
+	Triggered    bool `json:"triggered"`
+	Active       bool `json:"active"`
+	// Suppressed marks frames discarded as a lighting change
+	Suppressed bool `json:"suppressed,omitempty"`
+}
@@ -0,0 +1,136 @@
+package motion
+
+import (
+	"time"
+
+	"github.com/kai5263499/droidcam-sentry/backend/internal/config"
+)
+
+// Settings are the effective motion detection parameters for one camera.
+type Settings struct {
+	// Threshold is the motion area in pixels that counts as motion
+	Threshold float64
+	// ThresholdPercent, when set, overrides Threshold with a percentage of
+	// the reference area (zones if configured, otherwise the frame)
+	ThresholdPercent float64
+	Zones            []config.ZoneConfig
+	Tripwires        []config.TripwireConfig
+	MinArea          int
+	Interval         time.Duration
+	Algorithm        string
+	History          int
+	VarThreshold     float64
+	DetectShadows    bool
+	KernelSize       int
+	Dist2Threshold   float64
+	LearningRate     float64
+	DiffThreshold    int
+	BlockSize        int
+	// TriggerFrames of the last WindowFrames analysed frames must exceed the
+	// threshold to start an event; ContinueRatio scales the threshold that
+	// keeps one going
+	TriggerFrames int
+	WindowFrames  int
+	ContinueRatio float64
+	// SuppressLighting discards frames whose brightness (LightingDelta grey
+	// levels) or histogram (LightingShift) changes abruptly
+	SuppressLighting bool
+	LightingDelta    float64
+	LightingShift    float64
+	// TrackMaxMissed analysed frames may pass before a lost track ends;
+	// TrackMaxDistance is the largest move between frames as a fraction of
+	// the frame diagonal
+	TrackMaxMissed   int
+	TrackMaxDistance float64
+	// Stationary reports regions that differ from a long-term background
+	// (learned at LongTermRate) without moving for StationaryHold
+	Stationary     bool
+	StationaryHold time.Duration
+	LongTermRate   float64
+}
+
+// SettingsFromConfig merges a camera's motion block over the global motion
+// defaults and converts the result to detector settings.
+func SettingsFromConfig(cam config.CameraConfig, global config.MotionConfig) Settings {
+	m := global.Merge(cam.Motion)
+
+	s := Settings{
+		Threshold:        cam.MotionThreshold,
+		ThresholdPercent: cam.MotionThresholdPercent,
+		Zones:            cam.Zones,
+		Tripwires:        cam.Tripwires,
+		MinArea:          m.MinArea,
+		Interval:         time.Duration(m.DetectionIntervalMs) * time.Millisecond,
+		Algorithm:        m.Algorithm,
+		History:          m.History,
+		VarThreshold:     m.VarThreshold,
+		KernelSize:       m.KernelSize,
+		Dist2Threshold:   m.Dist2Threshold,
+		LearningRate:     m.LearningRate,
+		DiffThreshold:    m.DiffThreshold,
+		BlockSize:        m.BlockSize,
+		TriggerFrames:    m.TriggerFrames,
+		WindowFrames:     m.WindowFrames,
+		ContinueRatio:    m.ContinueRatio,
+		LightingDelta:    m.LightingDelta,
+		LightingShift:    m.LightingShift,
+		TrackMaxMissed:   m.TrackMaxMissed,
+		TrackMaxDistance: m.TrackMaxDistance,
+		StationaryHold:   time.Duration(m.StationarySeconds) * time.Second,
+		LongTermRate:     m.LongTermRate,
+	}
+	if m.DetectShadows != nil {
+		s.DetectShadows = *m.DetectShadows
+	}
+	if m.SuppressLighting != nil {
+		s.SuppressLighting = *m.SuppressLighting
+	}
+	if m.Stationary != nil {
+		s.Stationary = *m.Stationary
+	}
+	if s.Algorithm == "" {
+		s.Algorithm = config.AlgorithmMOG2
+	}
+	if s.KernelSize <= 0 {
+		s.KernelSize = 5
+	}
+	if s.ContinueRatio <= 0 || s.ContinueRatio > 1 {
+		s.ContinueRatio = 1
+	}
+	return s
+}
+
+// sameModel reports whether two settings can share a background model, i.e.
+// only thresholds, zones or timing differ
+func (s Settings) sameModel(o Settings) bool {
+	return s.Algorithm == o.Algorithm &&
+		s.History == o.History &&
+		s.VarThreshold == o.VarThreshold &&
+		s.DetectShadows == o.DetectShadows &&
+		s.Dist2Threshold == o.Dist2Threshold &&
+		s.LearningRate == o.LearningRate &&
+		s.DiffThreshold == o.DiffThreshold &&
+		s.BlockSize == o.BlockSize
+}
+
+// ThresholdArea returns the motion area in pixels that triggers detection
+// for a frame whose reference area is refArea pixels
+func (s Settings) ThresholdArea(refArea float64) float64 {
+	if s.ThresholdPercent > 0 {
+		return s.ThresholdPercent / 100 * refArea
+	}
+	return s.Threshold
+}
+
+// ContinueArea returns the lower motion area that keeps an event going
+func (s Settings) ContinueArea(refArea float64) float64 {
+	return s.ThresholdArea(refArea) * s.ContinueRatio
+}
+
+// Score expresses a motion area as a percentage of the reference area
+func Score(area int, refArea float64) float64 {
+	if refArea <= 0 {
+		return 0
+	}
+	return float64(area) / refArea * 100
+}
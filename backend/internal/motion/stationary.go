@@ -0,0 +1,123 @@
+package motion
+
+import (
+	"image"
+	"time"
+)
+
+// Stationary event types
+const (
+	ObjectAppeared = "object_appeared"
+	ObjectRemoved  = "object_removed"
+)
+
+const (
+	// minStationaryIoU is the box overlap that matches a changed region to
+	// a candidate from the previous frame
+	minStationaryIoU = 0.5
+	// stationaryMaxMissed is how many analysed frames a candidate may go
+	// unseen, e.g. while someone walks in front of it, before it's dropped
+	stationaryMaxMissed = 5
+)
+
+// StationaryEvent reports a region that has differed from the long-term
+// background without moving for the hold time: an object that was left
+// behind or one that was taken away
+type StationaryEvent struct {
+	Type string `json:"type"`
+	// Zone is the zone the region's centre lies in, if any
+	Zone     string          `json:"zone,omitempty"`
+	Box      image.Rectangle `json:"box"`
+	Since    time.Time       `json:"since"`
+	Time     time.Time       `json:"time"`
+	Duration time.Duration   `json:"duration"`
+	// Before and After are JPEG crops of the region from the long-term
+	// background and the current frame
+	Before []byte `json:"-"`
+	After  []byte `json:"-"`
+}
+
+type stationaryCandidate struct {
+	box    image.Rectangle
+	since  time.Time
+	missed int
+	fired  bool
+}
+
+// Stationary follows changed regions that have stopped moving and reports
+// each once it has stayed put for Hold. Which kind of change it was is left
+// to the caller.
+type Stationary struct {
+	Hold time.Duration
+
+	candidates []*stationaryCandidate
+}
+
+// NewStationary creates a stationary region monitor
+func NewStationary(hold time.Duration) *Stationary {
+	return &Stationary{Hold: hold}
+}
+
+// Update matches the changed, non-moving regions of one analysed frame to
+// the candidates and returns those that just reached the hold time
+func (s *Stationary) Update(now time.Time, boxes []image.Rectangle) []StationaryEvent {
+	matched := make([]bool, len(s.candidates))
+	for _, box := range boxes {
+		best, bestIoU := -1, minStationaryIoU
+		for i, c := range s.candidates {
+			if matched[i] {
+				continue
+			}
+			if iou := boxIoU(c.box, box); iou >= bestIoU {
+				best, bestIoU = i, iou
+			}
+		}
+		if best < 0 {
+			s.candidates = append(s.candidates, &stationaryCandidate{box: box, since: now})
+			matched = append(matched, true)
+			continue
+		}
+		matched[best] = true
+		s.candidates[best].box = box
+		s.candidates[best].missed = 0
+	}
+
+	var events []StationaryEvent
+	kept := s.candidates[:0]
+	for i, c := range s.candidates {
+		if !matched[i] {
+			c.missed++
+			if c.missed > stationaryMaxMissed {
+				continue
+			}
+		}
+		kept = append(kept, c)
+
+		if !c.fired && matched[i] && now.Sub(c.since) >= s.Hold {
+			c.fired = true
+			events = append(events, StationaryEvent{
+				Box:      c.box,
+				Since:    c.since,
+				Time:     now,
+				Duration: now.Sub(c.since),
+			})
+		}
+	}
+	s.candidates = kept
+	return events
+}
+
+// Reset forgets all candidates, e.g. after the background was re-learned
+func (s *Stationary) Reset() {
+	s.candidates = nil
+}
+
+// ClassifyChange tells an object that appeared from one that was removed by
+// comparing the edge pixels of the region in the current frame with those
+// in the background: whatever is there now but wasn't before adds edges.
+func ClassifyChange(frameEdges, backgroundEdges int) string {
+	if frameEdges >= backgroundEdges {
+		return ObjectAppeared
+	}
+	return ObjectRemoved
+}
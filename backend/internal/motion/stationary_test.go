@@ -0,0 +1,63 @@
+package motion
+
+import (
+	"image"
+	"testing"
+	"time"
+)
+
+func TestStationaryFiresAfterHold(t *testing.T) {
+	s := NewStationary(30 * time.Second)
+	start := time.Unix(1000, 0)
+	parcel := image.Rect(100, 100, 140, 130)
+
+	var fired []StationaryEvent
+	for sec := 0; sec <= 60; sec += 5 {
+		// The box jitters a little from frame to frame
+		box := parcel.Add(image.Pt(sec%2, 0))
+		fired = append(fired, s.Update(start.Add(time.Duration(sec)*time.Second), []image.Rectangle{box})...)
+	}
+
+	if len(fired) != 1 {
+		t.Fatalf("Expected 1 stationary event, got %d", len(fired))
+	}
+	if fired[0].Duration != 30*time.Second {
+		t.Errorf("Expected the event to fire after 30s, got %s", fired[0].Duration)
+	}
+	if !fired[0].Since.Equal(start) {
+		t.Errorf("Expected the event to start at %v, got %v", start, fired[0].Since)
+	}
+}
+
+func TestStationarySurvivesShortOcclusion(t *testing.T) {
+	s := NewStationary(10 * time.Second)
+	start := time.Unix(1000, 0)
+	box := image.Rect(0, 0, 50, 50)
+
+	s.Update(start, []image.Rectangle{box})
+	for i := 1; i <= stationaryMaxMissed; i++ {
+		s.Update(start.Add(time.Duration(i)*time.Second), nil)
+	}
+	fired := s.Update(start.Add(10*time.Second), []image.Rectangle{box})
+	if len(fired) != 1 {
+		t.Fatalf("Expected the occluded region to keep its start time, got %d events", len(fired))
+	}
+
+	s.Reset()
+	s.Update(start, []image.Rectangle{box})
+	for i := 1; i <= stationaryMaxMissed+1; i++ {
+		s.Update(start.Add(time.Duration(i)*time.Second), nil)
+	}
+	if fired := s.Update(start.Add(10*time.Second), []image.Rectangle{box}); len(fired) != 0 {
+		t.Errorf("Expected a region gone too long to start over, got %d events", len(fired))
+	}
+}
+
+func TestClassifyChange(t *testing.T) {
+	if got := ClassifyChange(500, 120); got != ObjectAppeared {
+		t.Errorf("Expected new edges to mean an object appeared, got %s", got)
+	}
+	if got := ClassifyChange(80, 400); got != ObjectRemoved {
+		t.Errorf("Expected lost edges to mean an object was removed, got %s", got)
+	}
+}
@@ -0,0 +1,144 @@
+//go:build opencv
+
+package motion
+
+import (
+	"image"
+
+	"github.com/kai5263499/droidcam-sentry/backend/internal/config"
+	"gocv.io/x/gocv"
+)
+
+// Subtractor produces a binary foreground mask (0 or 255) for each frame.
+type Subtractor interface {
+	Apply(frame gocv.Mat, mask *gocv.Mat)
+	Close()
+}
+
+// NewSubtractor creates the background model selected by s.Algorithm
+func NewSubtractor(s Settings) Subtractor {
+	switch s.Algorithm {
+	case config.AlgorithmKNN:
+		return &knnSubtractor{
+			model:   gocv.NewBackgroundSubtractorKNNWithParams(s.History, s.Dist2Threshold, s.DetectShadows),
+			shadows: s.DetectShadows,
+		}
+	case config.AlgorithmRunningAverage:
+		return &runningAverageSubtractor{
+			rate:       s.LearningRate,
+			threshold:  float32(s.DiffThreshold),
+			gray:       gocv.NewMat(),
+			background: gocv.NewMat(),
+		}
+	case config.AlgorithmBlockDiff:
+		return &blockDiffSubtractor{
+			diff: NewBlockDiff(s.BlockSize, s.DiffThreshold, s.LearningRate),
+			gray: gocv.NewMat(),
+		}
+	default:
+		return &mog2Subtractor{
+			model:   gocv.NewBackgroundSubtractorMOG2WithParams(s.History, s.VarThreshold, s.DetectShadows),
+			shadows: s.DetectShadows,
+		}
+	}
+}
+
+// foregroundLevel separates foreground (255) from the grey (127) MOG2 and
+// KNN assign to shadow pixels when shadow detection is on
+const foregroundLevel = 200
+
+// mog2Subtractor is OpenCV's Gaussian-mixture background model
+type mog2Subtractor struct {
+	model   gocv.BackgroundSubtractorMOG2
+	shadows bool
+}
+
+func (m *mog2Subtractor) Apply(frame gocv.Mat, mask *gocv.Mat) {
+	m.model.Apply(frame, mask)
+
+	// Shadows are marked grey in the mask; keep only confident foreground
+	if m.shadows {
+		gocv.Threshold(*mask, mask, foregroundLevel, 255, gocv.ThresholdBinary)
+	}
+}
+
+func (m *mog2Subtractor) Close() {
+	m.model.Close()
+}
+
+// knnSubtractor is OpenCV's K-nearest-neighbours background model, which
+// copes better with gradual lighting changes than MOG2
+type knnSubtractor struct {
+	model   gocv.BackgroundSubtractorKNN
+	shadows bool
+}
+
+func (k *knnSubtractor) Apply(frame gocv.Mat, mask *gocv.Mat) {
+	k.model.Apply(frame, mask)
+
+	if k.shadows {
+		gocv.Threshold(*mask, mask, foregroundLevel, 255, gocv.ThresholdBinary)
+	}
+}
+
+func (k *knnSubtractor) Close() {
+	k.model.Close()
+}
+
+// runningAverageSubtractor differences each blurred grayscale frame against
+// an exponentially weighted running average of previous frames
+type runningAverageSubtractor struct {
+	rate       float64
+	threshold  float32
+	gray       gocv.Mat
+	background gocv.Mat
+}
+
+func (r *runningAverageSubtractor) Apply(frame gocv.Mat, mask *gocv.Mat) {
+	gocv.CvtColor(frame, &r.gray, gocv.ColorBGRToGray)
+	gocv.GaussianBlur(r.gray, &r.gray, image.Pt(21, 21), 0, 0, gocv.BorderDefault)
+
+	// Seed the background with the first frame (or after a size change)
+	if r.background.Empty() || r.background.Cols() != r.gray.Cols() || r.background.Rows() != r.gray.Rows() {
+		r.gray.ConvertTo(&r.background, gocv.MatTypeCV32F)
+		r.gray.CopyTo(mask)
+		mask.SetTo(gocv.NewScalar(0, 0, 0, 0))
+		return
+	}
+
+	background := gocv.NewMat()
+	defer background.Close()
+	gocv.ConvertScaleAbs(r.background, &background, 1, 0)
+
+	gocv.AbsDiff(r.gray, background, mask)
+	gocv.Threshold(*mask, mask, r.threshold, 255, gocv.ThresholdBinary)
+
+	gocv.AccumulatedWeighted(r.gray, &r.background, r.rate)
+}
+
+func (r *runningAverageSubtractor) Close() {
+	r.gray.Close()
+	r.background.Close()
+}
+
+// blockDiffSubtractor adapts the pure-Go BlockDiff detector
+type blockDiffSubtractor struct {
+	diff *BlockDiff
+	gray gocv.Mat
+}
+
+func (b *blockDiffSubtractor) Apply(frame gocv.Mat, mask *gocv.Mat) {
+	gocv.CvtColor(frame, &b.gray, gocv.ColorBGRToGray)
+
+	out := b.diff.Apply(b.gray.ToBytes(), b.gray.Cols(), b.gray.Rows())
+	result, err := gocv.NewMatFromBytes(b.gray.Rows(), b.gray.Cols(), gocv.MatTypeCV8U, out)
+	if err != nil {
+		return
+	}
+	defer result.Close()
+	result.CopyTo(mask)
+}
+
+func (b *blockDiffSubtractor) Close() {
+	b.gray.Close()
+}
@@ -0,0 +1,264 @@
+package motion
+
+import (
+	"image"
+	"math"
+	"sort"
+	"time"
+)
+
+const (
+	// minTrackIoU is the box overlap that matches a region to a track
+	// regardless of how far its centroid moved
+	minTrackIoU = 0.1
+	// maxTrackPath caps the stored path; longer paths are thinned out
+	maxTrackPath = 256
+	// maxEndedTracks caps the ended tracks kept between marks; the oldest
+	// are dropped first
+	maxEndedTracks = 64
+	// minLabelIoU is the overlap an object detection box needs with a
+	// track to label it
+	minLabelIoU = 0.3
+)
+
+// TrackPoint is one observed position of a track
+type TrackPoint struct {
+	Time  time.Time   `json:"time"`
+	Point image.Point `json:"point"`
+}
+
+// Track follows one moving region across analysed frames
+type Track struct {
+	ID int `json:"id"`
+	// Label is the object class, once object detection has seen the track
+	Label string `json:"label,omitempty"`
+	// Box is the latest bounding box
+	Box image.Rectangle `json:"box"`
+	// Path is the centroid history; long paths keep every other point
+	Path      []TrackPoint `json:"path"`
+	FirstSeen time.Time    `json:"first_seen"`
+	LastSeen  time.Time    `json:"last_seen"`
+	// Entry and Exit are where the track was first and last seen
+	Entry image.Point `json:"entry"`
+	Exit  image.Point `json:"exit"`
+	// Distance is the length of the path in pixels and Speed the average
+	// speed in pixels per second
+	Distance float64       `json:"distance"`
+	Speed    float64       `json:"speed"`
+	Dwell    time.Duration `json:"dwell"`
+	// Ended is set once the track has been lost for MaxMissed frames
+	Ended bool `json:"ended"`
+
+	missed int
+}
+
+func (t *Track) observe(now time.Time, r Region) {
+	if len(t.Path) > 0 {
+		t.Distance += distance(t.Exit, r.Centroid)
+	}
+	t.Box = r.Box
+	t.Exit = r.Centroid
+	t.LastSeen = now
+	t.Dwell = now.Sub(t.FirstSeen)
+	if secs := t.Dwell.Seconds(); secs > 0 {
+		t.Speed = t.Distance / secs
+	}
+	t.missed = 0
+
+	t.Path = append(t.Path, TrackPoint{Time: now, Point: r.Centroid})
+	if len(t.Path) > maxTrackPath {
+		thinned := t.Path[:0]
+		for i, p := range t.Path {
+			if i%2 == 0 || i == len(t.Path)-1 {
+				thinned = append(thinned, p)
+			}
+		}
+		t.Path = thinned
+	}
+}
+
+func (t *Track) clone() Track {
+	c := *t
+	c.Path = append([]TrackPoint(nil), t.Path...)
+	return c
+}
+
+// Tracker assigns persistent IDs to moving regions by matching each frame's
+// regions to the existing tracks on box overlap and centroid distance.
+type Tracker struct {
+	// MaxMissed is how many analysed frames a track may go unmatched before
+	// it ends, and MaxDistance how far (in pixels) a centroid may move
+	// between matches
+	MaxMissed   int
+	MaxDistance float64
+	// Lines are tripwires that tracks are checked against
+	Lines []Line
+
+	nextID    int
+	active    []*Track
+	ended     []Track
+	crossings []Crossing
+}
+
+// NewTracker creates a tracker
+func NewTracker(maxMissed int, maxDistance float64) *Tracker {
+	return &Tracker{MaxMissed: maxMissed, MaxDistance: maxDistance, nextID: 1}
+}
+
+// Update matches the regions of one analysed frame to the tracks, starting
+// tracks for unmatched regions and ending tracks that stayed unmatched too
+// long. It returns the active tracks.
+func (t *Tracker) Update(now time.Time, regions []Region) []Track {
+	t.crossings = nil
+
+	type candidate struct {
+		track, region int
+		iou, dist     float64
+	}
+
+	var candidates []candidate
+	for i, tr := range t.active {
+		for j, r := range regions {
+			c := candidate{track: i, region: j, iou: boxIoU(tr.Box, r.Box), dist: distance(tr.Exit, r.Centroid)}
+			if c.iou >= minTrackIoU || c.dist <= t.MaxDistance {
+				candidates = append(candidates, c)
+			}
+		}
+	}
+	sort.Slice(candidates, func(a, b int) bool {
+		if candidates[a].iou != candidates[b].iou {
+			return candidates[a].iou > candidates[b].iou
+		}
+		return candidates[a].dist < candidates[b].dist
+	})
+
+	trackUsed := make([]bool, len(t.active))
+	regionUsed := make([]bool, len(regions))
+	for _, c := range candidates {
+		if trackUsed[c.track] || regionUsed[c.region] {
+			continue
+		}
+		trackUsed[c.track], regionUsed[c.region] = true, true
+		tr := t.active[c.track]
+		from := tr.Exit
+		tr.observe(now, regions[c.region])
+		t.cross(tr, from, now)
+	}
+
+	kept := t.active[:0]
+	for i, tr := range t.active {
+		if !trackUsed[i] {
+			tr.missed++
+			if tr.missed > t.MaxMissed {
+				tr.Ended = true
+				t.ended = append(t.ended, tr.clone())
+				if n := len(t.ended) - maxEndedTracks; n > 0 {
+					t.ended = append(t.ended[:0], t.ended[n:]...)
+				}
+				continue
+			}
+		}
+		kept = append(kept, tr)
+	}
+	t.active = kept
+
+	for j, r := range regions {
+		if regionUsed[j] {
+			continue
+		}
+		tr := &Track{ID: t.nextID, FirstSeen: now, Entry: r.Centroid}
+		t.nextID++
+		tr.observe(now, r)
+		t.active = append(t.active, tr)
+	}
+
+	return t.Active()
+}
+
+// cross records the tripwires a track crossed moving from from to its
+// latest position
+func (t *Tracker) cross(tr *Track, from image.Point, now time.Time) {
+	for _, l := range t.Lines {
+		if dir, ok := l.Cross(from, tr.Exit); ok {
+			t.crossings = append(t.crossings, Crossing{
+				Line:      l.Name,
+				Direction: dir,
+				TrackID:   tr.ID,
+				Label:     tr.Label,
+				Time:      now,
+				Point:     tr.Exit,
+			})
+		}
+	}
+}
+
+// Crossings returns the tripwire crossings of the last Update
+func (t *Tracker) Crossings() []Crossing {
+	return append([]Crossing(nil), t.crossings...)
+}
+
+// Label sets the object class of the active track that box (an object
+// detection) overlaps most. It reports whether a track was labelled.
+func (t *Tracker) Label(box image.Rectangle, label string) bool {
+	var best *Track
+	bestIoU := minLabelIoU
+	for _, tr := range t.active {
+		if iou := boxIoU(tr.Box, box); iou >= bestIoU {
+			best, bestIoU = tr, iou
+		}
+	}
+	if best == nil {
+		return false
+	}
+	best.Label = label
+	return true
+}
+
+// Active returns copies of the tracks currently followed
+func (t *Tracker) Active() []Track {
+	tracks := make([]Track, len(t.active))
+	for i, tr := range t.active {
+		tracks[i] = tr.clone()
+	}
+	return tracks
+}
+
+// Tracks returns the tracks that ended since the last Mark, at most
+// maxEndedTracks of them, followed by the active ones
+func (t *Tracker) Tracks() []Track {
+	tracks := make([]Track, 0, len(t.ended)+len(t.active))
+	for _, tr := range t.ended {
+		tracks = append(tracks, tr.clone())
+	}
+	return append(tracks, t.Active()...)
+}
+
+// Mark forgets ended tracks, so Tracks only covers what follows, e.g. one
+// motion event
+func (t *Tracker) Mark() {
+	t.ended = nil
+}
+
+// Reset drops all tracks. IDs keep increasing so they stay unique.
+func (t *Tracker) Reset() {
+	t.active = nil
+	t.ended = nil
+}
+
+func distance(a, b image.Point) float64 {
+	return math.Hypot(float64(a.X-b.X), float64(a.Y-b.Y))
+}
+
+// boxIoU is the intersection over union of two boxes
+func boxIoU(a, b image.Rectangle) float64 {
+	inter := a.Intersect(b)
+	if inter.Empty() {
+		return 0
+	}
+	i := float64(inter.Dx() * inter.Dy())
+	u := float64(a.Dx()*a.Dy()+b.Dx()*b.Dy()) - i
+	if u <= 0 {
+		return 0
+	}
+	return i / u
+}
@@ -0,0 +1,183 @@
+package motion
+
+import (
+	"image"
+	"testing"
+	"time"
+
+	"github.com/kai5263499/droidcam-sentry/backend/internal/config"
+)
+
+func region(x, y int) Region {
+	return Region{Box: image.Rect(x-10, y-10, x+10, y+10), Centroid: image.Pt(x, y), Area: 400}
+}
+
+func TestTrackerKeepsIDsAcrossFrames(t *testing.T) {
+	tr := NewTracker(2, 50)
+	now := time.Unix(0, 0)
+
+	// Two people walking in opposite directions
+	for i := 0; i < 5; i++ {
+		now = now.Add(100 * time.Millisecond)
+		tr.Update(now, []Region{region(100+i*15, 100), region(400-i*15, 300)})
+	}
+
+	tracks := tr.Active()
+	if len(tracks) != 2 {
+		t.Fatalf("Expected 2 tracks, got %d", len(tracks))
+	}
+	if tracks[0].ID != 1 || tracks[1].ID != 2 {
+		t.Errorf("Expected IDs 1 and 2, got %d and %d", tracks[0].ID, tracks[1].ID)
+	}
+	first := tracks[0]
+	if first.Entry != image.Pt(100, 100) || first.Exit != image.Pt(160, 100) {
+		t.Errorf("Expected entry (100,100) and exit (160,100), got %v and %v", first.Entry, first.Exit)
+	}
+	if len(first.Path) != 5 {
+		t.Errorf("Expected 5 path points, got %d", len(first.Path))
+	}
+	if first.Distance != 60 {
+		t.Errorf("Expected distance 60, got %f", first.Distance)
+	}
+	if first.Dwell != 400*time.Millisecond {
+		t.Errorf("Expected dwell 400ms, got %s", first.Dwell)
+	}
+	if first.Speed != 150 {
+		t.Errorf("Expected speed 150 px/s, got %f", first.Speed)
+	}
+}
+
+func TestTrackerSurvivesMissedFrames(t *testing.T) {
+	tr := NewTracker(2, 50)
+	now := time.Unix(0, 0)
+
+	tr.Update(now, []Region{region(100, 100)})
+	tr.Update(now.Add(100*time.Millisecond), nil)
+	tr.Update(now.Add(200*time.Millisecond), nil)
+	active := tr.Update(now.Add(300*time.Millisecond), []Region{region(120, 100)})
+
+	if len(active) != 1 || active[0].ID != 1 {
+		t.Fatalf("Expected track 1 to continue after 2 missed frames, got %+v", active)
+	}
+
+	for i := 4; i <= 6; i++ {
+		tr.Update(now.Add(time.Duration(i)*100*time.Millisecond), nil)
+	}
+	if len(tr.Active()) != 0 {
+		t.Error("Expected the track to end after 3 missed frames")
+	}
+	tracks := tr.Tracks()
+	if len(tracks) != 1 || !tracks[0].Ended || tracks[0].Exit != image.Pt(120, 100) {
+		t.Errorf("Expected one ended track exiting at (120,100), got %+v", tracks)
+	}
+
+	tr.Mark()
+	if len(tr.Tracks()) != 0 {
+		t.Error("Expected Mark to forget ended tracks")
+	}
+}
+
+func TestTrackerCapsEndedTracks(t *testing.T) {
+	tr := NewTracker(0, 50)
+	now := time.Unix(0, 0)
+
+	// A blob flickering on and off starts a new track every other frame
+	for i := 0; i < 4*maxEndedTracks; i++ {
+		now = now.Add(100 * time.Millisecond)
+		if i%2 == 0 {
+			tr.Update(now, []Region{region(100, 100)})
+		} else {
+			tr.Update(now, nil)
+		}
+	}
+
+	tracks := tr.Tracks()
+	if len(tracks) != maxEndedTracks {
+		t.Fatalf("Expected %d ended tracks, got %d", maxEndedTracks, len(tracks))
+	}
+	if last := tracks[len(tracks)-1].ID; last != 2*maxEndedTracks {
+		t.Errorf("Expected the newest track %d to be kept, got %d", 2*maxEndedTracks, last)
+	}
+
+	tr.Update(now.Add(100*time.Millisecond), []Region{region(100, 100)})
+	tr.Reset()
+	if len(tr.Tracks()) != 0 {
+		t.Error("Expected Reset to drop all tracks")
+	}
+}
+
+func TestTrackerStartsNewTrackForDistantRegion(t *testing.T) {
+	tr := NewTracker(2, 50)
+	now := time.Unix(0, 0)
+
+	tr.Update(now, []Region{region(100, 100)})
+	active := tr.Update(now.Add(100*time.Millisecond), []Region{region(300, 300)})
+
+	if len(active) != 2 {
+		t.Fatalf("Expected a new track for a region too far away, got %d tracks", len(active))
+	}
+	if active[1].ID != 2 {
+		t.Errorf("Expected new track ID 2, got %d", active[1].ID)
+	}
+}
+
+func TestTrackerPrefersOverlap(t *testing.T) {
+	tr := NewTracker(2, 100)
+	now := time.Unix(0, 0)
+
+	tr.Update(now, []Region{region(100, 100), region(160, 100)})
+	// Both regions move right; each is within distance of both tracks
+	active := tr.Update(now.Add(100*time.Millisecond), []Region{region(165, 100), region(105, 100)})
+
+	if active[0].Exit != image.Pt(105, 100) || active[1].Exit != image.Pt(165, 100) {
+		t.Errorf("Expected tracks matched to the overlapping regions, got %v and %v", active[0].Exit, active[1].Exit)
+	}
+}
+
+func TestLineCross(t *testing.T) {
+	// A horizontal line drawn left to right: "in" is downwards
+	l := Line{Name: "gate", A: image.Pt(0, 100), B: image.Pt(200, 100)}
+
+	if dir, ok := l.Cross(image.Pt(50, 80), image.Pt(50, 120)); !ok || dir != config.DirectionIn {
+		t.Errorf("Expected a downward move to cross in, got %q %v", dir, ok)
+	}
+	if dir, ok := l.Cross(image.Pt(50, 120), image.Pt(50, 80)); !ok || dir != config.DirectionOut {
+		t.Errorf("Expected an upward move to cross out, got %q %v", dir, ok)
+	}
+	if _, ok := l.Cross(image.Pt(250, 80), image.Pt(250, 120)); ok {
+		t.Error("Expected a move past the end of the line not to cross")
+	}
+	if _, ok := l.Cross(image.Pt(50, 80), image.Pt(150, 90)); ok {
+		t.Error("Expected a move along one side not to cross")
+	}
+}
+
+func TestTrackerReportsCrossings(t *testing.T) {
+	tr := NewTracker(2, 50)
+	tr.Lines = []Line{{Name: "gate", A: image.Pt(0, 100), B: image.Pt(200, 100)}}
+	now := time.Unix(0, 0)
+
+	tr.Update(now, []Region{region(50, 80)})
+	if !tr.Label(image.Rect(38, 68, 62, 92), "person") {
+		t.Fatal("Expected the detection to label the track")
+	}
+	tr.Update(now.Add(100*time.Millisecond), []Region{region(50, 95)})
+	if len(tr.Crossings()) != 0 {
+		t.Fatal("Expected no crossing before the line")
+	}
+	tr.Update(now.Add(200*time.Millisecond), []Region{region(50, 110)})
+
+	crossings := tr.Crossings()
+	if len(crossings) != 1 {
+		t.Fatalf("Expected 1 crossing, got %d", len(crossings))
+	}
+	c := crossings[0]
+	if c.Line != "gate" || c.Direction != config.DirectionIn || c.TrackID != 1 || c.Label != "person" {
+		t.Errorf("Expected person track 1 crossing gate inward, got %+v", c)
+	}
+
+	tr.Update(now.Add(300*time.Millisecond), []Region{region(50, 125)})
+	if len(tr.Crossings()) != 0 {
+		t.Error("Expected crossings to be reported once")
+	}
+}
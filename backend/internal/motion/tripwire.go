@@ -0,0 +1,61 @@
+package motion
+
+import (
+	"image"
+	"time"
+
+	"github.com/kai5263499/droidcam-sentry/backend/internal/config"
+)
+
+// Line is a tripwire in frame pixels
+type Line struct {
+	Name string
+	A, B image.Point
+}
+
+// Crossing is a track crossing a tripwire
+type Crossing struct {
+	Line string `json:"line"`
+	// Direction is config.DirectionIn (left to right of the line, looking
+	// from A to B) or config.DirectionOut
+	Direction string      `json:"direction"`
+	TrackID   int         `json:"track_id"`
+	Label     string      `json:"label,omitempty"`
+	Time      time.Time   `json:"time"`
+	Point     image.Point `json:"point"`
+}
+
+// LinesFromConfig converts tripwire configs to lines for a frame size
+func LinesFromConfig(tripwires []config.TripwireConfig, width, height int) []Line {
+	lines := make([]Line, len(tripwires))
+	for i, t := range tripwires {
+		a, b := t.Line(width, height)
+		lines[i] = Line{Name: t.Name, A: a, B: b}
+	}
+	return lines
+}
+
+// Cross reports whether moving from p to q crosses the line and in which
+// direction. A point exactly on the line counts as its right side, so a
+// track stopping on the line is counted once.
+func (l Line) Cross(p, q image.Point) (string, bool) {
+	fromLeft := side(l.A, l.B, p) < 0
+	toLeft := side(l.A, l.B, q) < 0
+	if fromLeft == toLeft {
+		return "", false
+	}
+	// The line's end points must lie on either side of the move
+	if s1, s2 := side(p, q, l.A), side(p, q, l.B); (s1 > 0 && s2 > 0) || (s1 < 0 && s2 < 0) {
+		return "", false
+	}
+	if fromLeft {
+		return config.DirectionIn, true
+	}
+	return config.DirectionOut, true
+}
+
+// side is positive when p is right of the line from a to b (in image
+// coordinates, with y pointing down), negative when left
+func side(a, b, p image.Point) int {
+	return (b.X-a.X)*(p.Y-a.Y) - (b.Y-a.Y)*(p.X-a.X)
+}
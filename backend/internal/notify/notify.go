@@ -0,0 +1,109 @@
+// Package notify delivers event notifications to webhooks.
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/kai5263499/droidcam-sentry/backend/internal/config"
+	"github.com/rs/zerolog/log"
+)
+
+// Event types
+const (
+	EventMotion         = "motion"
+	EventCrossing       = "line_crossing"
+	EventDwell          = "dwell"
+	EventObjectAppeared = "object_appeared"
+	EventObjectRemoved  = "object_removed"
+	EventTamper         = "tamper"
+	EventTamperCleared  = "tamper_cleared"
+	EventPhone          = "phone"
+	EventPhoneCleared   = "phone_cleared"
+)
+
+// PriorityHigh marks events that need attention right away, such as
+// tampering or a phone running out of battery
+const PriorityHigh = "high"
+
+// Event is the JSON body POSTed to each webhook
+type Event struct {
+	Type string `json:"type"`
+	// Priority is empty for routine events
+	Priority string      `json:"priority,omitempty"`
+	Camera   string      `json:"camera"`
+	Time     time.Time   `json:"time"`
+	Message  string      `json:"message,omitempty"`
+	Labels   []string    `json:"labels,omitempty"`
+	Data     interface{} `json:"data,omitempty"`
+}
+
+// Notifier sends events to the configured webhooks. It is safe for
+// concurrent use and can be reconfigured at runtime.
+type Notifier struct {
+	mu       sync.RWMutex
+	webhooks []string
+	client   *http.Client
+}
+
+// New creates a notifier for the given configuration
+func New(cfg config.NotificationsConfig) *Notifier {
+	n := &Notifier{}
+	n.Configure(cfg)
+	return n
+}
+
+// Configure replaces the webhooks and timeout
+func (n *Notifier) Configure(cfg config.NotificationsConfig) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.webhooks = append([]string(nil), cfg.Webhooks...)
+	n.client = &http.Client{Timeout: time.Duration(cfg.TimeoutSeconds) * time.Second}
+}
+
+// Send delivers ev in the background, logging failures
+func (n *Notifier) Send(ev Event) {
+	if n == nil {
+		return
+	}
+	go func() {
+		for _, err := range n.Deliver(ev) {
+			log.Error().Str("camera", ev.Camera).Str("event", ev.Type).Err(err).Msg("Failed to deliver notification")
+		}
+	}()
+}
+
+// Deliver POSTs ev to every webhook and returns the failures
+func (n *Notifier) Deliver(ev Event) []error {
+	n.mu.RLock()
+	webhooks, client := n.webhooks, n.client
+	n.mu.RUnlock()
+
+	if len(webhooks) == 0 {
+		return nil
+	}
+
+	body, err := json.Marshal(ev)
+	if err != nil {
+		return []error{err}
+	}
+
+	var errs []error
+	for _, hook := range webhooks {
+		resp, err := client.Post(hook, "application/json", bytes.NewReader(body))
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			errs = append(errs, fmt.Errorf("webhook %s returned %s", hook, resp.Status))
+		}
+	}
+	return errs
+}
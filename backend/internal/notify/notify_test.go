@@ -0,0 +1,39 @@
+package notify
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kai5263499/droidcam-sentry/backend/internal/config"
+)
+
+func TestDeliver(t *testing.T) {
+	received := make(chan Event, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var ev Event
+		if err := json.NewDecoder(r.Body).Decode(&ev); err != nil {
+			t.Errorf("Failed to decode notification: %v", err)
+		}
+		received <- ev
+	}))
+	defer srv.Close()
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+
+	n := New(config.NotificationsConfig{Webhooks: []string{srv.URL, failing.URL}, TimeoutSeconds: 5})
+	errs := n.Deliver(Event{Type: EventMotion, Camera: "front", Time: time.Now(), Labels: []string{"person"}})
+	if len(errs) != 1 {
+		t.Errorf("Expected one failed webhook, got %v", errs)
+	}
+
+	ev := <-received
+	if ev.Camera != "front" || len(ev.Labels) != 1 || ev.Labels[0] != "person" {
+		t.Errorf("Unexpected notification %+v", ev)
+	}
+}
@@ -0,0 +1,142 @@
+//go:build opencv
+
+package objects
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"sync"
+
+	"github.com/kai5263499/droidcam-sentry/backend/internal/config"
+	"gocv.io/x/gocv"
+)
+
+// cropPadding grows motion regions before cropping so the model sees some
+// context around the moving part of an object
+const cropPadding = 0.25
+
+// ErrClosed is returned by Detect once the detector was closed, e.g. by a
+// configuration reload while a camera was still using it
+var ErrClosed = errors.New("object detector closed")
+
+// Detector runs an object detection network on frames. A single network is
+// shared by all cameras, so calls are serialised.
+type Detector struct {
+	mu     sync.Mutex
+	net    gocv.Net
+	cfg    config.ObjectsConfig
+	labels []string
+	closed bool
+}
+
+// New loads the model described by cfg
+func New(cfg config.ObjectsConfig) (*Detector, error) {
+	labels, err := LoadLabels(cfg.Labels)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read labels: %w", err)
+	}
+
+	net := gocv.ReadNet(cfg.Model, cfg.ModelConfig)
+	if net.Empty() {
+		return nil, fmt.Errorf("failed to load model %s", cfg.Model)
+	}
+	if err := net.SetPreferableBackend(gocv.NetBackendDefault); err != nil {
+		net.Close()
+		return nil, err
+	}
+	if err := net.SetPreferableTarget(gocv.NetTargetCPU); err != nil {
+		net.Close()
+		return nil, err
+	}
+
+	return &Detector{net: net, cfg: cfg, labels: labels}, nil
+}
+
+// Config returns the configuration the detector was loaded with
+func (d *Detector) Config() config.ObjectsConfig {
+	return d.cfg
+}
+
+// Detect finds objects in frame. With crops enabled and motion regions
+// given, only the (padded) regions are classified, which finds small
+// objects a whole-frame pass at the model's input size would miss.
+func (d *Detector) Detect(frame gocv.Mat, regions []image.Rectangle) ([]Object, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.closed {
+		return nil, ErrClosed
+	}
+
+	if !d.cfg.Crops || len(regions) == 0 {
+		found, err := d.detect(frame)
+		if err != nil {
+			return nil, err
+		}
+		return NMS(found, d.cfg.NMSThreshold), nil
+	}
+
+	bounds := image.Rect(0, 0, frame.Cols(), frame.Rows())
+	var all []Object
+	for _, r := range regions {
+		padX, padY := int(float64(r.Dx())*cropPadding), int(float64(r.Dy())*cropPadding)
+		r = image.Rect(r.Min.X-padX, r.Min.Y-padY, r.Max.X+padX, r.Max.Y+padY).Intersect(bounds)
+		if r.Empty() {
+			continue
+		}
+
+		crop := frame.Region(r)
+		found, err := d.detect(crop)
+		crop.Close()
+		if err != nil {
+			return nil, err
+		}
+		for _, o := range found {
+			o.Box = o.Box.Add(r.Min)
+			all = append(all, o)
+		}
+	}
+	return NMS(all, d.cfg.NMSThreshold), nil
+}
+
+// detect runs the network on img and decodes its output. Callers must hold
+// the lock.
+func (d *Detector) detect(img gocv.Mat) ([]Object, error) {
+	size := image.Pt(d.cfg.InputSize, d.cfg.InputSize)
+
+	var blob gocv.Mat
+	if d.cfg.Type == config.ModelSSD {
+		blob = gocv.BlobFromImage(img, 1/127.5, size, gocv.NewScalar(127.5, 127.5, 127.5, 0), false, false)
+	} else {
+		blob = gocv.BlobFromImage(img, 1/255.0, size, gocv.NewScalar(0, 0, 0, 0), true, false)
+	}
+	defer blob.Close()
+
+	d.net.SetInput(blob, "")
+	out := d.net.Forward("")
+	defer out.Close()
+
+	data, err := out.DataPtrFloat32()
+	if err != nil {
+		return nil, err
+	}
+
+	if d.cfg.Type == config.ModelSSD {
+		return DecodeSSD(data, d.labels, d.cfg.Confidence, img.Cols(), img.Rows()), nil
+	}
+	sx := float64(img.Cols()) / float64(d.cfg.InputSize)
+	sy := float64(img.Rows()) / float64(d.cfg.InputSize)
+	return DecodeYOLO(data, out.Size(), d.labels, d.cfg.Confidence, sx, sy), nil
+}
+
+// Close releases the network. Detect calls that are running finish first
+// and later ones return ErrClosed.
+func (d *Detector) Close() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.closed {
+		return
+	}
+	d.closed = true
+	d.net.Close()
+}
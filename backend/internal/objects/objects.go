@@ -0,0 +1,205 @@
+// Package objects classifies what caused motion with a CPU object detection
+// model run through OpenCV's DNN module.
+package objects
+
+import (
+	"bufio"
+	"image"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Object is a detected object. Box is in frame coordinates.
+type Object struct {
+	Label      string          `json:"label"`
+	Confidence float64         `json:"confidence"`
+	Box        image.Rectangle `json:"box"`
+}
+
+// Allowed returns the objects whose label is in allow. An empty allow-list
+// allows everything.
+func Allowed(objects []Object, allow []string) []Object {
+	if len(allow) == 0 {
+		return objects
+	}
+	var out []Object
+	for _, o := range objects {
+		if slices.Contains(allow, o.Label) {
+			out = append(out, o)
+		}
+	}
+	return out
+}
+
+// Labels returns the distinct labels of objects, sorted
+func Labels(objects []Object) []string {
+	var labels []string
+	for _, o := range objects {
+		if !slices.Contains(labels, o.Label) {
+			labels = append(labels, o.Label)
+		}
+	}
+	sort.Strings(labels)
+	return labels
+}
+
+// LoadLabels reads class names, one per line. An empty path yields the COCO
+// class names most YOLO and SSD models are trained on.
+func LoadLabels(path string) ([]string, error) {
+	if path == "" {
+		return COCOLabels, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var labels []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		labels = append(labels, strings.TrimSpace(scanner.Text()))
+	}
+	return labels, scanner.Err()
+}
+
+// NMS drops overlapping boxes of the same label, keeping the most confident.
+// Boxes overlapping a kept one by more than threshold (IoU) are dropped.
+func NMS(objects []Object, threshold float64) []Object {
+	sorted := append([]Object(nil), objects...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Confidence > sorted[j].Confidence })
+
+	var kept []Object
+	for _, o := range sorted {
+		overlaps := false
+		for _, k := range kept {
+			if k.Label == o.Label && iou(k.Box, o.Box) > threshold {
+				overlaps = true
+				break
+			}
+		}
+		if !overlaps {
+			kept = append(kept, o)
+		}
+	}
+	return kept
+}
+
+func iou(a, b image.Rectangle) float64 {
+	inter := a.Intersect(b)
+	if inter.Empty() {
+		return 0
+	}
+	ia := float64(inter.Dx() * inter.Dy())
+	union := float64(a.Dx()*a.Dy()+b.Dx()*b.Dy()) - ia
+	if union <= 0 {
+		return 0
+	}
+	return ia / union
+}
+
+// DecodeYOLO decodes a YOLO output tensor with dims [1, rows, cols]. Both
+// the YOLOv5 layout (one row per candidate: cx, cy, w, h, objectness, class
+// scores) and the transposed YOLOv8 layout (one column per candidate,
+// without objectness) are accepted. Coordinates are in model input pixels
+// and are scaled by sx, sy to the frame.
+func DecodeYOLO(data []float32, dims []int, labels []string, minConfidence, sx, sy float64) []Object {
+	if len(dims) != 3 {
+		return nil
+	}
+	a, b := dims[1], dims[2]
+	classes := len(labels)
+
+	var n, stride, classOffset int
+	at := func(i, j int) float32 { return data[i*b+j] }
+	switch {
+	case b == classes+5:
+		n, stride, classOffset = a, b, 5
+	case b == classes+4:
+		n, stride, classOffset = a, b, 4
+	case a == classes+4:
+		// YOLOv8: attributes are rows, candidates are columns
+		n, stride, classOffset = b, a, 4
+		at = func(i, j int) float32 { return data[j*b+i] }
+	default:
+		return nil
+	}
+	if len(data) < n*stride {
+		return nil
+	}
+
+	var found []Object
+	for i := 0; i < n; i++ {
+		objectness := float32(1)
+		if classOffset == 5 {
+			objectness = at(i, 4)
+			if float64(objectness) < minConfidence {
+				continue
+			}
+		}
+
+		best, bestScore := -1, float32(0)
+		for c := 0; c < classes; c++ {
+			if s := at(i, classOffset+c); s > bestScore {
+				best, bestScore = c, s
+			}
+		}
+		confidence := float64(bestScore * objectness)
+		if best < 0 || confidence < minConfidence {
+			continue
+		}
+
+		cx, cy, w, h := float64(at(i, 0)), float64(at(i, 1)), float64(at(i, 2)), float64(at(i, 3))
+		found = append(found, Object{
+			Label:      labels[best],
+			Confidence: confidence,
+			Box: image.Rect(
+				int((cx-w/2)*sx), int((cy-h/2)*sy),
+				int((cx+w/2)*sx), int((cy+h/2)*sy),
+			),
+		})
+	}
+	return found
+}
+
+// DecodeSSD decodes an SSD detection output of N rows of [image id, class
+// id, confidence, x1, y1, x2, y2] with coordinates relative (0-1) to a
+// width x height frame. Class ids index labels; models with a background
+// class at index 0 need a labels file listing it.
+func DecodeSSD(data []float32, labels []string, minConfidence float64, width, height int) []Object {
+	var found []Object
+	for i := 0; i+7 <= len(data); i += 7 {
+		confidence := float64(data[i+2])
+		class := int(data[i+1])
+		if confidence < minConfidence || class < 0 || class >= len(labels) {
+			continue
+		}
+		found = append(found, Object{
+			Label:      labels[class],
+			Confidence: confidence,
+			Box: image.Rect(
+				int(float64(data[i+3])*float64(width)), int(float64(data[i+4])*float64(height)),
+				int(float64(data[i+5])*float64(width)), int(float64(data[i+6])*float64(height)),
+			),
+		})
+	}
+	return found
+}
+
+// COCOLabels are the 80 COCO class names in model output order
+var COCOLabels = []string{
+	"person", "bicycle", "car", "motorcycle", "airplane", "bus", "train", "truck", "boat",
+	"traffic light", "fire hydrant", "stop sign", "parking meter", "bench", "bird", "cat",
+	"dog", "horse", "sheep", "cow", "elephant", "bear", "zebra", "giraffe", "backpack",
+	"umbrella", "handbag", "tie", "suitcase", "frisbee", "skis", "snowboard", "sports ball",
+	"kite", "baseball bat", "baseball glove", "skateboard", "surfboard", "tennis racket",
+	"bottle", "wine glass", "cup", "fork", "knife", "spoon", "bowl", "banana", "apple",
+	"sandwich", "orange", "broccoli", "carrot", "hot dog", "pizza", "donut", "cake", "chair",
+	"couch", "potted plant", "bed", "dining table", "toilet", "tv", "laptop", "mouse",
+	"remote", "keyboard", "cell phone", "microwave", "oven", "toaster", "sink",
+	"refrigerator", "book", "clock", "vase", "scissors", "teddy bear", "hair drier",
+	"toothbrush",
+}
@@ -0,0 +1,56 @@
+package objects
+
+import (
+	"image"
+	"testing"
+)
+
+func TestDecodeYOLO(t *testing.T) {
+	labels := []string{"person", "car"}
+
+	// YOLOv5 layout: two candidates of cx, cy, w, h, objectness, scores
+	v5 := []float32{
+		100, 100, 40, 80, 0.9, 0.8, 0.1,
+		300, 200, 60, 40, 0.2, 0.1, 0.9,
+	}
+	found := DecodeYOLO(v5, []int{1, 2, 7}, labels, 0.5, 2, 2)
+	if len(found) != 1 || found[0].Label != "person" {
+		t.Fatalf("Expected one person, got %+v", found)
+	}
+	if want := image.Rect(160, 120, 240, 280); found[0].Box != want {
+		t.Errorf("Expected box %v, got %v", want, found[0].Box)
+	}
+
+	// YOLOv8 layout: attributes are rows, candidates are columns
+	v8 := []float32{
+		100, 300, // cx
+		100, 200, // cy
+		40, 60, // w
+		80, 40, // h
+		0.1, 0.2, // person
+		0.3, 0.95, // car
+	}
+	found = DecodeYOLO(v8, []int{1, 6, 2}, labels, 0.5, 1, 1)
+	if len(found) != 1 || found[0].Label != "car" || found[0].Box != image.Rect(270, 180, 330, 220) {
+		t.Fatalf("Expected one car, got %+v", found)
+	}
+}
+
+func TestNMSAndAllowed(t *testing.T) {
+	objects := []Object{
+		{Label: "person", Confidence: 0.6, Box: image.Rect(0, 0, 100, 100)},
+		{Label: "person", Confidence: 0.9, Box: image.Rect(5, 5, 105, 105)},
+		{Label: "dog", Confidence: 0.7, Box: image.Rect(0, 0, 100, 100)},
+	}
+	kept := NMS(objects, 0.45)
+	if len(kept) != 2 || kept[0].Confidence != 0.9 {
+		t.Fatalf("Expected the best person and the dog, got %+v", kept)
+	}
+
+	if got := Labels(Allowed(kept, []string{"person", "car"})); len(got) != 1 || got[0] != "person" {
+		t.Errorf("Expected only person to be allowed, got %v", got)
+	}
+	if got := Allowed(kept, nil); len(got) != 2 {
+		t.Errorf("Expected an empty allow-list to allow everything, got %+v", got)
+	}
+}
@@ -0,0 +1,254 @@
+package recorder
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Recording describes a finished recording file on disk.
+type Recording struct {
+	Name    string    `json:"name"`
+	Path    string    `json:"path"`
+	Camera  string    `json:"camera"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"timestamp"`
+	Labels  []string  `json:"labels,omitempty"`
+	// Faces are the parts of the recording where faces were seen
+	Faces []TimeRange `json:"faces,omitempty"`
+}
+
+// TimeRange is a span of a recording in seconds from its start
+type TimeRange struct {
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+}
+
+// Timeline collects time ranges, merging ranges closer than Gap seconds
+type Timeline struct {
+	Gap    float64
+	ranges []TimeRange
+}
+
+// Add marks start to end as covered
+func (t *Timeline) Add(start, end float64) {
+	if n := len(t.ranges); n > 0 && start-t.ranges[n-1].End <= t.Gap {
+		last := &t.ranges[n-1]
+		last.Start = min(last.Start, start)
+		last.End = max(last.End, end)
+		return
+	}
+	t.ranges = append(t.ranges, TimeRange{Start: start, End: end})
+}
+
+// Ranges returns the collected ranges in order
+func (t *Timeline) Ranges() []TimeRange {
+	return append([]TimeRange(nil), t.ranges...)
+}
+
+// Metadata is stored next to a recording as <name>.json. The sidecar keeps
+// the base name of the recording so it survives the AVI to MP4 conversion.
+type Metadata struct {
+	Camera string    `json:"camera"`
+	Start  time.Time `json:"start"`
+	End    time.Time `json:"end"`
+	// Labels are the object classes seen during the recording, sorted, with
+	// the best confidence of each in Confidence.
+	Labels     []string           `json:"labels,omitempty"`
+	Confidence map[string]float64 `json:"confidence,omitempty"`
+	Faces      []TimeRange        `json:"faces,omitempty"`
+	// Motion is when the event that triggered the recording started and
+	// last saw motion, in seconds into the file
+	Motion *TimeRange `json:"motion,omitempty"`
+}
+
+// EventRange returns when the recorded event started and last saw motion,
+// in seconds into a file of the given length. Sidecars without Motion fall
+// back to the recording's start and end times: the file holds the
+// pre-buffer, then runs from Start to End, which is post seconds after the
+// last motion. It reports false if the sidecar has neither.
+func (m Metadata) EventRange(duration, post float64) (TimeRange, bool) {
+	if m.Motion != nil {
+		return *m.Motion, true
+	}
+	if m.Start.IsZero() || !m.End.After(m.Start) {
+		return TimeRange{}, false
+	}
+	start := math.Max(duration-m.End.Sub(m.Start).Seconds(), 0)
+	return TimeRange{Start: start, End: math.Max(duration-post, start)}, true
+}
+
+// MetadataPath returns the sidecar path for a recording file.
+func MetadataPath(path string) string {
+	return strings.TrimSuffix(path, filepath.Ext(path)) + ".json"
+}
+
+// ReadMetadata reads the sidecar of a recording file.
+func ReadMetadata(path string) (Metadata, error) {
+	var meta Metadata
+	data, err := os.ReadFile(MetadataPath(path))
+	if err != nil {
+		return meta, err
+	}
+	err = json.Unmarshal(data, &meta)
+	return meta, err
+}
+
+// WriteMetadata writes the sidecar of a recording file.
+func WriteMetadata(path string, meta Metadata) error {
+	data, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(MetadataPath(path), data, 0644)
+}
+
+// RemoveMetadata deletes the sidecar of a recording file, if any.
+func RemoveMetadata(path string) error {
+	if err := os.Remove(MetadataPath(path)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// List returns the MP4 recordings under dir, newest first. A missing
+// directory yields no recordings.
+func List(camera, dir string) ([]Recording, error) {
+	return listByExt(camera, dir, ".mp4")
+}
+
+// ListUnconverted returns AVI files under dir that were never converted to
+// MP4, for example because the process stopped mid-conversion.
+func ListUnconverted(camera, dir string) ([]Recording, error) {
+	return listByExt(camera, dir, ".avi")
+}
+
+func listByExt(camera, dir, ext string) ([]Recording, error) {
+	recordings := make([]Recording, 0)
+	if _, err := os.Stat(dir); errors.Is(err, os.ErrNotExist) {
+		return recordings, nil
+	}
+
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return nil
+		}
+		if !strings.HasSuffix(strings.ToLower(info.Name()), ext) {
+			return nil
+		}
+
+		rec := Recording{
+			Name:    info.Name(),
+			Path:    path,
+			Camera:  camera,
+			Size:    info.Size(),
+			ModTime: info.ModTime(),
+		}
+		if meta, err := ReadMetadata(path); err == nil {
+			rec.Labels = meta.Labels
+			rec.Faces = meta.Faces
+		}
+		recordings = append(recordings, rec)
+		return nil
+	})
+
+	sort.Slice(recordings, func(i, j int) bool { return recordings[i].ModTime.After(recordings[j].ModTime) })
+	return recordings, err
+}
+
+// Purge deletes recordings last modified before cutoff and returns the ones
+// removed. With dryRun set nothing is deleted.
+func Purge(recordings []Recording, cutoff time.Time, dryRun bool) ([]Recording, error) {
+	purged := make([]Recording, 0)
+	for _, rec := range recordings {
+		if !rec.ModTime.Before(cutoff) {
+			continue
+		}
+		if !dryRun {
+			if err := os.Remove(rec.Path); err != nil {
+				return purged, fmt.Errorf("failed to delete %s: %w", rec.Path, err)
+			}
+			if err := RemoveMetadata(rec.Path); err != nil {
+				return purged, fmt.Errorf("failed to delete metadata of %s: %w", rec.Path, err)
+			}
+		}
+		purged = append(purged, rec)
+	}
+	return purged, nil
+}
+
+// ProbeDuration uses ffprobe to get a video's duration
+func ProbeDuration(path string) (time.Duration, error) {
+	cmd := exec.Command("ffprobe",
+		"-v", "error",
+		"-show_entries", "format=duration",
+		"-of", "default=noprint_wrappers=1:nokey=1",
+		path)
+
+	output, err := cmd.Output()
+	if err != nil {
+		return 0, err
+	}
+
+	seconds, err := strconv.ParseFloat(strings.TrimSpace(string(output)), 64)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+// FormatDuration formats a duration as MM:SS
+func FormatDuration(d time.Duration) string {
+	seconds := int(d.Seconds())
+	return fmt.Sprintf("%d:%02d", seconds/60, seconds%60)
+}
+
+// ConvertToMP4 converts an AVI file to MP4 using ffmpeg and deletes the AVI
+// on success. It returns the path of the MP4 file.
+func ConvertToMP4(aviPath string) (string, error) {
+	return ConvertToMP4Context(context.Background(), aviPath)
+}
+
+// ConvertToMP4Context is ConvertToMP4 with ffmpeg killed once ctx is done
+func ConvertToMP4Context(ctx context.Context, aviPath string) (string, error) {
+	mp4Path := strings.TrimSuffix(aviPath, filepath.Ext(aviPath)) + ".mp4"
+
+	// ffmpeg command: convert AVI to MP4 with H.264 codec
+	// -i input.avi: input file
+	// -c:v libx264: use H.264 video codec
+	// -preset fast: encoding speed/compression tradeoff
+	// -crf 23: constant rate factor (quality, 18-28 range, lower = better)
+	// -c:a aac: use AAC audio codec (if there's audio)
+	// -y: overwrite output file if exists
+	cmd := exec.CommandContext(ctx, "ffmpeg",
+		"-i", aviPath,
+		"-c:v", "libx264",
+		"-preset", "fast",
+		"-crf", "23",
+		"-c:a", "aac",
+		"-y",
+		mp4Path,
+	)
+
+	// Capture output for debugging
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("ffmpeg conversion failed: %w\nOutput: %s", err, string(output))
+	}
+
+	// Delete original AVI file after successful conversion
+	if err := os.Remove(aviPath); err != nil {
+		return mp4Path, fmt.Errorf("converted but failed to delete AVI file: %w", err)
+	}
+
+	return mp4Path, nil
+}
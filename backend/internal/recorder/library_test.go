@@ -0,0 +1,115 @@
+package recorder
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestMetadataPath(t *testing.T) {
+	if got := MetadataPath("/rec/cam_20240101_120000.avi"); got != "/rec/cam_20240101_120000.json" {
+		t.Errorf("Expected sidecar next to the recording, got %s", got)
+	}
+	if MetadataPath("a.avi") != MetadataPath("a.mp4") {
+		t.Error("Expected AVI and MP4 to share a sidecar")
+	}
+}
+
+func TestListReadsLabels(t *testing.T) {
+	dir := t.TempDir()
+	labelled := filepath.Join(dir, "cam_1.mp4")
+	plain := filepath.Join(dir, "cam_2.mp4")
+	for _, p := range []string{labelled, plain} {
+		if err := os.WriteFile(p, []byte("video"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	meta := Metadata{Camera: "cam", Start: time.Now(), End: time.Now(), Labels: []string{"car", "person"}}
+	if err := WriteMetadata(labelled, meta); err != nil {
+		t.Fatal(err)
+	}
+
+	recordings, err := List("cam", dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recordings) != 2 {
+		t.Fatalf("Expected the sidecar to be skipped, got %d recordings", len(recordings))
+	}
+	for _, rec := range recordings {
+		want := 0
+		if rec.Path == labelled {
+			want = 2
+		}
+		if len(rec.Labels) != want {
+			t.Errorf("Expected %d labels for %s, got %v", want, rec.Name, rec.Labels)
+		}
+	}
+}
+
+func TestPurgeRemovesMetadata(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "cam_1.mp4")
+	if err := os.WriteFile(path, []byte("video"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteMetadata(path, Metadata{Camera: "cam", Labels: []string{"dog"}}); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-48 * time.Hour)
+	if err := os.Chtimes(path, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	recordings, _ := List("cam", dir)
+	purged, err := Purge(recordings, time.Now().Add(-24*time.Hour), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(purged) != 1 {
+		t.Fatalf("Expected 1 purged recording, got %d", len(purged))
+	}
+	if _, err := os.Stat(MetadataPath(path)); !os.IsNotExist(err) {
+		t.Error("Expected the sidecar to be removed")
+	}
+}
+
+func TestTimelineMergesCloseRanges(t *testing.T) {
+	tl := Timeline{Gap: 1}
+	tl.Add(2, 2.5)
+	tl.Add(3, 3.5)
+	tl.Add(3.2, 4)
+	tl.Add(8, 8.5)
+
+	ranges := tl.Ranges()
+	want := []TimeRange{{Start: 2, End: 4}, {Start: 8, End: 8.5}}
+	if len(ranges) != len(want) {
+		t.Fatalf("Expected %d ranges, got %v", len(want), ranges)
+	}
+	for i := range want {
+		if ranges[i] != want[i] {
+			t.Errorf("Expected range %d to be %v, got %v", i, want[i], ranges[i])
+		}
+	}
+}
+
+func TestMetadataEventRange(t *testing.T) {
+	start := time.Unix(1000, 0)
+	meta := Metadata{Start: start, End: start.Add(20 * time.Second)}
+
+	// 25s file: 5s pre-buffer, then 20s of which the last 10s are post-buffer
+	r, ok := meta.EventRange(25, 10)
+	if !ok || r.Start != 5 || r.End != 15 {
+		t.Errorf("Expected the event from 5s to 15s, got %+v %v", r, ok)
+	}
+
+	meta.Motion = &TimeRange{Start: 4, End: 12.5}
+	if r, _ := meta.EventRange(25, 10); r != *meta.Motion {
+		t.Errorf("Expected the recorded motion range, got %+v", r)
+	}
+
+	if _, ok := (Metadata{}).EventRange(25, 10); ok {
+		t.Error("Expected a sidecar without times to give no range")
+	}
+}
@@ -7,9 +7,8 @@ import (
 	"fmt"
 	"log"
 	"os"
-	"os/exec"
 	"path/filepath"
-	"strings"
+	"sort"
 	"sync"
 	"time"
 
@@ -29,6 +28,11 @@ type VideoRecorder struct {
 	recordingStart    time.Time
 	framesSinceMotion int
 	currentFile       string
+	labels            map[string]float64
+	faces             Timeline
+	framesWritten     int
+	preFrames         int // pre-buffered frames the file starts with
+	motionFrames      int // frames written up to the last motion
 	mu                sync.Mutex
 }
 
@@ -65,6 +69,7 @@ func (r *VideoRecorder) AddFrame(frame gocv.Mat) {
 	// If recording, write frame
 	if r.isRecording && r.writer != nil && !frame.Empty() {
 		r.writer.Write(frame)
+		r.framesWritten++
 	}
 }
 
@@ -115,6 +120,8 @@ func (r *VideoRecorder) StartRecording() error {
 	r.isRecording = true
 	r.recordingStart = time.Now()
 	r.framesSinceMotion = 0
+	r.labels = make(map[string]float64)
+	r.faces = Timeline{Gap: 1}
 
 	log.Printf("[%s] Started recording: %s", r.Name, filename)
 
@@ -128,6 +135,8 @@ func (r *VideoRecorder) StartRecording() error {
 			}
 		}
 	})
+	r.framesWritten = frameCount
+	r.preFrames, r.motionFrames = frameCount, frameCount
 	log.Printf("[%s] Wrote %d pre-buffered frames", r.Name, frameCount)
 
 	return nil
@@ -137,6 +146,35 @@ func (r *VideoRecorder) OnMotion() {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	r.framesSinceMotion = 0
+	if r.isRecording {
+		r.motionFrames = r.framesWritten
+	}
+}
+
+// Label records an object class seen during the current recording, keeping
+// the best confidence per label. It is ignored while not recording.
+func (r *VideoRecorder) Label(label string, confidence float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.isRecording {
+		return
+	}
+	if confidence > r.labels[label] {
+		r.labels[label] = confidence
+	}
+}
+
+// MarkFaces records that faces were visible in the latest frame, covering
+// the following span (the time until the next face check). Offsets are
+// taken from the frames written so far, so they line up with the file.
+func (r *VideoRecorder) MarkFaces(span time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.isRecording {
+		return
+	}
+	at := float64(r.framesWritten) / r.FPS
+	r.faces.Add(at, at+span.Seconds())
 }
 
 func (r *VideoRecorder) Update() bool {
@@ -161,40 +199,17 @@ func (r *VideoRecorder) Update() bool {
 
 // convertAVItoMP4 converts an AVI file to MP4 using ffmpeg
 func (r *VideoRecorder) convertAVItoMP4(aviPath string) error {
-	// Generate MP4 filename
-	mp4Path := strings.TrimSuffix(aviPath, ".avi") + ".mp4"
-
 	log.Printf("[%s] Converting %s to MP4...", r.Name, filepath.Base(aviPath))
 
-	// ffmpeg command: convert AVI to MP4 with H.264 codec
-	// -i input.avi: input file
-	// -c:v libx264: use H.264 video codec
-	// -preset fast: encoding speed/compression tradeoff
-	// -crf 23: constant rate factor (quality, 18-28 range, lower = better)
-	// -c:a aac: use AAC audio codec (if there's audio)
-	// -y: overwrite output file if exists
-	cmd := exec.Command("ffmpeg",
-		"-i", aviPath,
-		"-c:v", "libx264",
-		"-preset", "fast",
-		"-crf", "23",
-		"-c:a", "aac",
-		"-y",
-		mp4Path,
-	)
-
-	// Capture output for debugging
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		log.Printf("[%s] FFmpeg conversion failed: %v\nOutput: %s", r.Name, err, string(output))
-		return fmt.Errorf("ffmpeg conversion failed: %w", err)
+	mp4Path, err := ConvertToMP4(aviPath)
+	if mp4Path == "" {
+		log.Printf("[%s] FFmpeg conversion failed: %v", r.Name, err)
+		return err
 	}
 
 	log.Printf("[%s] Successfully converted to %s", r.Name, filepath.Base(mp4Path))
-
-	// Delete original AVI file after successful conversion
-	if err := os.Remove(aviPath); err != nil {
-		log.Printf("[%s] Warning: failed to delete AVI file: %v", r.Name, err)
+	if err != nil {
+		log.Printf("[%s] Warning: %v", r.Name, err)
 	} else {
 		log.Printf("[%s] Deleted original AVI file", r.Name)
 	}
@@ -210,8 +225,27 @@ func (r *VideoRecorder) stopRecording() {
 
 	duration := time.Since(r.recordingStart)
 	log.Printf("[%s] Stopped recording (duration: %s, file: %s)", r.Name, duration, r.currentFile)
+	wasRecording := r.isRecording
 	r.isRecording = false
 
+	if wasRecording && r.currentFile != "" {
+		meta := Metadata{
+			Camera:     r.Name,
+			Start:      r.recordingStart,
+			End:        r.recordingStart.Add(duration),
+			Confidence: r.labels,
+			Faces:      r.faces.Ranges(),
+			Motion:     &TimeRange{Start: float64(r.preFrames) / r.FPS, End: float64(r.motionFrames) / r.FPS},
+		}
+		for label := range r.labels {
+			meta.Labels = append(meta.Labels, label)
+		}
+		sort.Strings(meta.Labels)
+		if err := WriteMetadata(r.currentFile, meta); err != nil {
+			log.Printf("[%s] Failed to write recording metadata: %v", r.Name, err)
+		}
+	}
+
 	// Convert AVI to MP4 in background (non-blocking)
 	if r.currentFile != "" {
 		aviFile := r.currentFile
@@ -5,31 +5,43 @@ package server
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
 	httpSwagger "github.com/swaggo/http-swagger"
 	"github.com/kai5263499/droidcam-sentry/backend/internal/config"
+	"github.com/kai5263499/droidcam-sentry/backend/internal/faces"
+	"github.com/kai5263499/droidcam-sentry/backend/internal/heatmap"
+	"github.com/kai5263499/droidcam-sentry/backend/internal/motion"
+	"github.com/kai5263499/droidcam-sentry/backend/internal/recorder"
 	"github.com/kai5263499/droidcam-sentry/backend/internal/surveillance"
+	"github.com/kai5263499/droidcam-sentry/backend/pkg/camera"
 )
 
 type Server struct {
 	cfg     *config.Config
 	survMgr *surveillance.Manager
 	srv     *http.Server
+	// addr is the listen address, which may differ from server.host and
+	// server.port when overridden on the command line
+	addr string
 }
 
-func New(cfg *config.Config, survMgr *surveillance.Manager) *Server {
+func New(cfg *config.Config, survMgr *surveillance.Manager, addr string) *Server {
 
 	return &Server{
 		cfg:     cfg,
 		survMgr: survMgr,
+		addr:    addr,
 	}
 }
 
@@ -38,8 +50,11 @@ func (s *Server) Start() error {
 
 	// API routes
 	mux.HandleFunc("/api/config", s.handleConfig)
+	mux.HandleFunc("/api/config/history", s.handleConfigHistory)
+	mux.HandleFunc("/api/config/history/", s.handleConfigVersion)
+	mux.HandleFunc("/api/config/rollback/", s.handleConfigRollback)
 	mux.HandleFunc("/api/cameras", s.handleCameras)
-	mux.HandleFunc("/api/cameras/", s.handleCameraUpdate)
+	mux.HandleFunc("/api/cameras/", s.handleCamera)
 	mux.HandleFunc("/api/status", s.handleStatus)
 	mux.HandleFunc("/api/recordings", s.handleRecordings)
 
@@ -56,6 +71,7 @@ func (s *Server) Start() error {
 	mux.HandleFunc("/api/recordings/play", s.handleRecordingPlay)
 	mux.HandleFunc("/api/recordings/download", s.handleRecordingDownload)
 	mux.HandleFunc("/api/recordings/delete", s.handleRecordingDelete)
+	mux.HandleFunc("/api/recordings/export", s.handleRecordingExport)
 
 	// Swagger UI
 	mux.HandleFunc("/swagger/", httpSwagger.WrapHandler)
@@ -69,7 +85,7 @@ func (s *Server) Start() error {
 		w.Write([]byte("OK"))
 	})
 
-	addr := fmt.Sprintf("%s:%d", s.cfg.Server.Host, s.cfg.Server.Port)
+	addr := s.addr
 	s.srv = &http.Server{
 		Addr:    addr,
 		Handler: s.corsMiddleware(mux),
@@ -90,7 +106,7 @@ func (s *Server) corsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-User")
 
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusOK)
@@ -208,6 +224,82 @@ func (s *Server) handleRecordingDownload(w http.ResponseWriter, r *http.Request)
 	io.Copy(w, file)
 }
 
+// handleRecordingExport godoc
+// @Summary Export a recording, optionally with faces pixelated
+// @Description With blur_faces, the recording is re-rendered with a face detector loaded from faces.model for this request; faces.enabled, which only controls face marking while recording, doesn't apply. The export stops when the client disconnects.
+// @Tags Recordings
+// @Param file query string true "Recording file path"
+// @Param blur_faces query bool false "Re-render the recording with detected faces pixelated"
+// @Produce application/octet-stream
+// @Success 200
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/recordings/export [get]
+func (s *Server) handleRecordingExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	filePath := r.URL.Query().Get("file")
+	if filePath == "" {
+		respondError(w, http.StatusBadRequest, "file parameter required")
+		return
+	}
+	if !s.isValidRecordingPath(filePath) {
+		respondError(w, http.StatusForbidden, "Access denied")
+		return
+	}
+	if _, err := os.Stat(filePath); os.IsNotExist(err) {
+		respondError(w, http.StatusNotFound, "Recording not found")
+		return
+	}
+
+	blur, _ := strconv.ParseBool(r.URL.Query().Get("blur_faces"))
+	if !blur {
+		s.handleRecordingDownload(w, r)
+		return
+	}
+
+	// The export loads its own detector rather than sharing the one that
+	// marks faces while recording: a configuration reload can't close it
+	// halfway through, which would leave later faces unblurred
+	facesCfg := s.cfg.Get().Faces
+	if facesCfg.Model == "" {
+		respondError(w, http.StatusBadRequest, "faces.model is not configured")
+		return
+	}
+	det, err := faces.New(facesCfg)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer det.Close()
+
+	dir, err := os.MkdirTemp("", "droidcam-export-")
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	exported, err := faces.Export(r.Context(), filePath, dir, det)
+	if r.Context().Err() != nil {
+		log.Printf("Export of %s cancelled: client went away", filepath.Base(filePath))
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to export recording: %v", err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "video/mp4")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filepath.Base(exported)))
+	http.ServeFile(w, r, exported)
+}
+
 // Validate recording path is within configured recording directories
 func (s *Server) isValidRecordingPath(filePath string) bool {
 	cfg := s.cfg.Get()
@@ -364,11 +456,14 @@ func (s *Server) handleMotionDetectionDisable(w http.ResponseWriter, r *http.Req
 
 // handleConfig godoc
 // @Summary Get or update configuration
+// @Description PUT accepts a partial document with server, motion, health and storage sections
 // @Tags Configuration
 // @Accept json
 // @Produce json
+// @Param dry_run query bool false "Validate and report changes without applying them (PUT only)"
 // @Success 200 {object} config.Snapshot
 // @Failure 400 {object} map[string]string
+// @Failure 422 {object} config.ValidationError
 // @Router /api/config [get]
 // @Router /api/config [put]
 func (s *Server) handleConfig(w http.ResponseWriter, r *http.Request) {
@@ -378,86 +473,282 @@ func (s *Server) handleConfig(w http.ResponseWriter, r *http.Request) {
 		respondJSON(w, http.StatusOK, cfg)
 
 	case http.MethodPut:
-		var updates map[string]interface{}
-		if err := json.NewDecoder(r.Body).Decode(&updates); err != nil {
-			respondError(w, http.StatusBadRequest, "Invalid JSON")
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "Failed to read request body")
 			return
 		}
 
-		s.cfg.Update(func(c *config.Config) {
-			s.applyConfigUpdates(c, updates)
-		})
-
-		if err := s.cfg.Save("config.yaml"); err != nil {
-			log.Printf("Warning: failed to save config: %v", err)
+		dryRun := isDryRun(r)
+		changes, err := s.cfg.Modify(func(snap *config.Snapshot) error {
+			return snap.MergeJSON(body)
+		}, dryRun)
+		if err != nil {
+			respondConfigError(w, err)
+			return
 		}
 
-		respondJSON(w, http.StatusOK, map[string]string{"status": "updated"})
+		if !dryRun {
+			s.saveConfig(r)
+		}
+		respondChanges(w, dryRun, changes, nil)
 
 	default:
 		respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
 	}
 }
 
+// handleConfigHistory godoc
+// @Summary List saved configuration versions
+// @Tags Configuration
+// @Produce json
+// @Success 200 {array} config.Version
+// @Failure 500 {object} map[string]string
+// @Router /api/config/history [get]
+func (s *Server) handleConfigHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	versions, err := s.cfg.History().List()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, versions)
+}
+
+// handleConfigVersion godoc
+// @Summary Get a saved configuration version
+// @Tags Configuration
+// @Param version path int true "Version number"
+// @Produce application/x-yaml
+// @Success 200
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/config/history/{version} [get]
+func (s *Server) handleConfigVersion(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	version, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/api/config/history/"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid version")
+		return
+	}
+
+	data, err := s.cfg.History().Read(version)
+	if err != nil {
+		respondConfigError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-yaml")
+	w.Write(data)
+}
+
+// handleConfigRollback godoc
+// @Summary Restore a saved configuration version
+// @Description Applies the version live and saves it as a new version
+// @Tags Configuration
+// @Param version path int true "Version number"
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 422 {object} config.ValidationError
+// @Router /api/config/rollback/{version} [post]
+func (s *Server) handleConfigRollback(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	version, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/api/config/rollback/"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid version")
+		return
+	}
+
+	changes, err := s.cfg.Rollback(version, requestUser(r))
+	if err != nil {
+		respondConfigError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"status":  "rolled back",
+		"version": version,
+		"changes": changes,
+	})
+}
+
+// cameraView is a camera's configuration together with the motion settings
+// in effect after merging its motion block over the global defaults
+type cameraView struct {
+	config.CameraConfig
+	EffectiveMotion config.MotionConfig `json:"effective_motion"`
+}
+
 // handleCameras godoc
-// @Summary List all cameras
+// @Summary List all cameras or add a camera
 // @Tags Cameras
+// @Accept json
 // @Produce json
-// @Success 200 {array} config.CameraConfig
+// @Param camera body config.CameraConfig false "Camera to add (POST only)"
+// @Param dry_run query bool false "Validate and report changes without applying them"
+// @Success 200 {array} cameraView
+// @Success 201 {object} config.CameraConfig
+// @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 422 {object} config.ValidationError
 // @Router /api/cameras [get]
+// @Router /api/cameras [post]
 func (s *Server) handleCameras(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
+	switch r.Method {
+	case http.MethodGet:
+		cfg := s.cfg.Get()
+		views := make([]cameraView, len(cfg.Cameras))
+		for i, cam := range cfg.Cameras {
+			views[i] = cameraView{CameraConfig: cam, EffectiveMotion: cfg.EffectiveMotion(cam)}
+		}
+		respondJSON(w, http.StatusOK, views)
+
+	case http.MethodPost:
+		var cam config.CameraConfig
+		if err := decodeBody(r, &cam, "camera"); err != nil {
+			respondConfigError(w, err)
+			return
+		}
+
+		dryRun := isDryRun(r)
+		changes, err := s.cfg.AddCamera(cam, dryRun)
+		if err != nil {
+			respondConfigError(w, err)
+			return
+		}
+
+		if dryRun {
+			respondChanges(w, dryRun, changes, cam)
+			return
+		}
+
+		s.saveConfig(r)
+		respondJSON(w, http.StatusCreated, cam)
+
+	default:
 		respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
-		return
 	}
-
-	cfg := s.cfg.Get()
-	respondJSON(w, http.StatusOK, cfg.Cameras)
 }
 
-// handleCameraUpdate godoc
-// @Summary Update camera configuration
+// handleCamera godoc
+// @Summary Get, replace or delete a camera
+// @Description PUT merges the body over the existing camera, so any subset of CameraConfig fields may be sent
 // @Tags Cameras
 // @Param name path string true "Camera name"
+// @Param camera body config.CameraConfig false "Camera fields to update (PUT only)"
+// @Param dry_run query bool false "Validate and report changes without applying them (PUT and DELETE)"
 // @Accept json
 // @Produce json
-// @Success 200 {object} map[string]string
+// @Success 200 {object} config.CameraConfig
 // @Failure 400 {object} map[string]string
 // @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 422 {object} config.ValidationError
+// @Router /api/cameras/{name} [get]
 // @Router /api/cameras/{name} [put]
-func (s *Server) handleCameraUpdate(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPut {
-		respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+// @Router /api/cameras/{name} [delete]
+func (s *Server) handleCamera(w http.ResponseWriter, r *http.Request) {
+	// Extract camera name and optional sub-resource from path
+	name, sub, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/cameras/"), "/")
+	if name == "" {
+		respondError(w, http.StatusBadRequest, "Camera name required")
 		return
 	}
 
-	// Extract camera name from path
-	name := r.URL.Path[len("/api/cameras/"):]
+	switch sub {
+	case "":
+	case "live":
+		s.handleCameraLiveView(w, r, name)
+		return
+	case "scores":
+		s.handleCameraScores(w, r, name)
+		return
+	case "heatmap":
+		s.handleCameraHeatmap(w, r, name)
+		return
+	case "counters":
+		s.handleCameraCounters(w, r, name)
+		return
+	case "tamper":
+		s.handleCameraTamper(w, r, name)
+		return
+	case "control":
+		s.handleCameraControl(w, r, name)
+		return
+	default:
+		respondError(w, http.StatusNotFound, "Not found")
+		return
+	}
 
-	var updates map[string]interface{}
-	if err := json.NewDecoder(r.Body).Decode(&updates); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid JSON")
+	cam, err := s.cfg.Camera(name)
+	if err != nil {
+		respondConfigError(w, err)
 		return
 	}
 
-	found := false
-	s.cfg.Update(func(c *config.Config) {
-		for i := range c.Cameras {
-			if c.Cameras[i].Name == name {
-				s.applyCameraUpdates(&c.Cameras[i], updates)
-				found = true
-				break
-			}
+	switch r.Method {
+	case http.MethodGet:
+		respondJSON(w, http.StatusOK, cameraView{CameraConfig: cam, EffectiveMotion: s.cfg.Get().EffectiveMotion(cam)})
+
+	case http.MethodPut:
+		// Decode over the existing camera so omitted fields keep their values
+		if err := decodeBody(r, &cam, "camera"); err != nil {
+			respondConfigError(w, err)
+			return
 		}
-	})
 
-	if !found {
-		respondError(w, http.StatusNotFound, "Camera not found")
-		return
-	}
+		dryRun := isDryRun(r)
+		changes, err := s.cfg.ReplaceCamera(name, cam, dryRun)
+		if err != nil {
+			respondConfigError(w, err)
+			return
+		}
+
+		if dryRun {
+			respondChanges(w, dryRun, changes, cam)
+			return
+		}
+
+		s.saveConfig(r)
+		respondJSON(w, http.StatusOK, cam)
+
+	case http.MethodDelete:
+		dryRun := isDryRun(r)
+		changes, err := s.cfg.RemoveCamera(name, dryRun)
+		if err != nil {
+			respondConfigError(w, err)
+			return
+		}
 
-	s.cfg.Save("config.yaml")
-	respondJSON(w, http.StatusOK, map[string]string{"status": "updated"})
+		if dryRun {
+			respondChanges(w, dryRun, changes, nil)
+			return
+		}
+
+		s.saveConfig(r)
+		respondJSON(w, http.StatusOK, map[string]string{
+			"status": "deleted",
+			"camera": name,
+		})
+
+	default:
+		respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
 }
 
 // handleStatus godoc
@@ -492,37 +783,77 @@ func (s *Server) handleRecordings(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusOK, recordings)
 }
 
-func (s *Server) applyConfigUpdates(c *config.Config, updates map[string]interface{}) {
-	// Apply motion config updates
-	if motion, ok := updates["motion"].(map[string]interface{}); ok {
-		if interval, ok := motion["detection_interval_ms"].(float64); ok {
-			c.Motion.DetectionIntervalMs = int(interval)
-		}
-		if minArea, ok := motion["min_area"].(float64); ok {
-			c.Motion.MinArea = int(minArea)
-		}
+// saveConfig persists the configuration after an API change
+func (s *Server) saveConfig(r *http.Request) {
+	if err := s.cfg.Save(requestUser(r)); err != nil {
+		log.Printf("Warning: failed to save config: %v", err)
 	}
+}
 
-	// Apply storage config updates
-	if storage, ok := updates["storage"].(map[string]interface{}); ok {
-		if maxSize, ok := storage["max_recording_size_mb"].(float64); ok {
-			c.Storage.MaxRecordingSizeMB = int(maxSize)
-		}
-		if retention, ok := storage["retention_days"].(float64); ok {
-			c.Storage.RetentionDays = int(retention)
-		}
+// requestUser identifies who made a request for the config history. The
+// server doesn't authenticate anyone, so a name from Basic auth or the X-User
+// header is recorded as client-supplied next to the remote address.
+func requestUser(r *http.Request) string {
+	user, _, ok := r.BasicAuth()
+	if !ok || user == "" {
+		user = r.Header.Get("X-User")
+	}
+	if user != "" {
+		return fmt.Sprintf("%s (client-supplied) via api@%s", user, r.RemoteAddr)
+	}
+	return "api@" + r.RemoteAddr
+}
+
+// decodeBody strictly decodes a JSON request body, reporting type mismatches
+// and unknown fields as field errors under prefix
+func decodeBody(r *http.Request, v interface{}, prefix string) error {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return err
 	}
+	return config.DecodeJSON(body, v, prefix)
+}
+
+// isDryRun reports whether the request asks for validation only
+func isDryRun(r *http.Request) bool {
+	dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dry_run"))
+	return dryRun
 }
 
-func (s *Server) applyCameraUpdates(cam *config.CameraConfig, updates map[string]interface{}) {
-	if enabled, ok := updates["enabled"].(bool); ok {
-		cam.Enabled = enabled
+// respondChanges reports the fields a configuration change touched (or would
+// touch, for a dry run)
+func respondChanges(w http.ResponseWriter, dryRun bool, changes []config.Change, camera interface{}) {
+	status := "updated"
+	if dryRun {
+		status = "valid"
 	}
-	if url, ok := updates["url"].(string); ok {
-		cam.URL = url
+
+	resp := map[string]interface{}{
+		"status":  status,
+		"dry_run": dryRun,
+		"changes": changes,
 	}
-	if threshold, ok := updates["motion_threshold"].(float64); ok {
-		cam.MotionThreshold = threshold
+	if camera != nil {
+		resp["camera"] = camera
+	}
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// respondConfigError maps configuration errors to HTTP status codes
+func respondConfigError(w http.ResponseWriter, err error) {
+	var verr *config.ValidationError
+	switch {
+	case errors.As(err, &verr):
+		respondJSON(w, http.StatusUnprocessableEntity, map[string]interface{}{
+			"error":  "invalid configuration",
+			"errors": verr.Errors,
+		})
+	case errors.Is(err, config.ErrCameraNotFound), errors.Is(err, config.ErrVersionNotFound):
+		respondError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, config.ErrCameraExists):
+		respondError(w, http.StatusConflict, err.Error())
+	default:
+		respondError(w, http.StatusBadRequest, err.Error())
 	}
 }
 
@@ -565,6 +896,269 @@ func (s *Server) handleCameraLive(w http.ResponseWriter, r *http.Request) {
 	}
 	defer s.survMgr.Unsubscribe(cameraName, frameChan)
 
+	streamMJPEG(w, r, frameChan)
+}
+
+// handleCameraLiveView godoc
+// @Summary Stream live or debug MJPEG video
+// @Description Without view this is the live stream. view=mask streams the foreground mask after dilation and zones, contours the frame with kept (red) and filtered (grey) contours and the measured areas, heat the accumulated motion.
+// @Tags Cameras
+// @Param name path string true "Camera name"
+// @Param view query string false "Debug view" Enums(mask, contours, heat)
+// @Produce multipart/x-mixed-replace
+// @Success 200
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/cameras/{name}/live [get]
+func (s *Server) handleCameraLiveView(w http.ResponseWriter, r *http.Request, cameraName string) {
+	if r.Method != http.MethodGet {
+		respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	view := r.URL.Query().Get("view")
+	if view == "" {
+		frameChan, err := s.survMgr.Subscribe(cameraName)
+		if err != nil {
+			respondError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		defer s.survMgr.Unsubscribe(cameraName, frameChan)
+
+		streamMJPEG(w, r, frameChan)
+		return
+	}
+
+	if !slices.Contains(motion.DebugViews, view) {
+		respondError(w, http.StatusBadRequest, fmt.Sprintf("Unknown view %q (use one of %s)", view, strings.Join(motion.DebugViews, ", ")))
+		return
+	}
+
+	frameChan, err := s.survMgr.SubscribeDebug(cameraName, view)
+	if err != nil {
+		respondError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	defer s.survMgr.UnsubscribeDebug(cameraName, view, frameChan)
+
+	streamMJPEG(w, r, frameChan)
+}
+
+// handleCameraScores godoc
+// @Summary Stream per-frame motion scores
+// @Description Server-sent events with one motion.Sample per analysed frame, for picking thresholds from real data
+// @Tags Cameras
+// @Param name path string true "Camera name"
+// @Produce text/event-stream
+// @Success 200 {object} motion.Sample
+// @Failure 404 {object} map[string]string
+// @Router /api/cameras/{name}/scores [get]
+func (s *Server) handleCameraScores(w http.ResponseWriter, r *http.Request, cameraName string) {
+	if r.Method != http.MethodGet {
+		respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	samples, err := s.survMgr.SubscribeScores(cameraName)
+	if err != nil {
+		respondError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	defer s.survMgr.UnsubscribeScores(cameraName, samples)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Cache-Control", "no-cache")
+
+	for {
+		select {
+		case sample, ok := <-samples:
+			if !ok {
+				return
+			}
+
+			data, err := json.Marshal(sample)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "data: %s\n\n", data)
+
+			if f, ok := w.(http.Flusher); ok {
+				f.Flush()
+			}
+
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// handleCameraHeatmap godoc
+// @Summary Get a camera's motion heatmap
+// @Description Renders where motion happened over the range as a colourised PNG blended over a recent snapshot. Older motion in the range fades.
+// @Tags Cameras
+// @Param name path string true "Camera name"
+// @Param range query string false "Time range such as 24h, 90m or 7d (default 24h)"
+// @Produce png
+// @Success 200 {file} binary
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/cameras/{name}/heatmap [get]
+func (s *Server) handleCameraHeatmap(w http.ResponseWriter, r *http.Request, cameraName string) {
+	if r.Method != http.MethodGet {
+		respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	span := 24 * time.Hour
+	if rng := r.URL.Query().Get("range"); rng != "" {
+		var err error
+		if span, err = heatmap.ParseRange(rng); err != nil {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	hm, err := s.survMgr.Heatmap(cameraName)
+	if errors.Is(err, config.ErrCameraNotFound) {
+		respondError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	png, err := heatmap.RenderPNG(hm.Sum(time.Now(), span), hm.Reference())
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Write(png)
+}
+
+// handleCameraCounters godoc
+// @Summary Get a camera's daily tripwire counts
+// @Description Returns the in/out crossing counts of each tripwire per day, for today and the preceding days.
+// @Tags Cameras
+// @Param name path string true "Camera name"
+// @Param days query int false "Number of days including today (default 1, max 366)"
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/cameras/{name}/counters [get]
+func (s *Server) handleCameraCounters(w http.ResponseWriter, r *http.Request, cameraName string) {
+	if r.Method != http.MethodGet {
+		respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	days := 1
+	if d := r.URL.Query().Get("days"); d != "" {
+		var err error
+		if days, err = strconv.Atoi(d); err != nil || days < 1 || days > 366 {
+			respondError(w, http.StatusBadRequest, "days must be between 1 and 366")
+			return
+		}
+	}
+
+	counts, err := s.survMgr.Counters(cameraName, days)
+	if errors.Is(err, config.ErrCameraNotFound) {
+		respondError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"camera": cameraName,
+		"days":   counts,
+	})
+}
+
+// handleCameraTamper godoc
+// @Summary Reset a camera's tamper reference
+// @Description Makes the camera's current view the reference for the moved check, e.g. after repositioning it on purpose.
+// @Tags Cameras
+// @Param name path string true "Camera name"
+// @Produce json
+// @Success 200 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/cameras/{name}/tamper [post]
+func (s *Server) handleCameraTamper(w http.ResponseWriter, r *http.Request, cameraName string) {
+	if r.Method != http.MethodPost {
+		respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	if err := s.survMgr.ResetTamperReference(cameraName); err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{
+		"status":  "reset",
+		"camera":  cameraName,
+		"message": fmt.Sprintf("Tamper reference reset for camera %s", cameraName),
+	})
+}
+
+// handleCameraControl godoc
+// @Summary Control a camera's phone
+// @Description Sends a DroidCam control command: torch (on, off or toggle), zoom (in, out or a level such as 2.5), autofocus, exposure_lock or white_balance_lock (on or off).
+// @Tags Cameras
+// @Param name path string true "Camera name"
+// @Param command body camera.Command true "Control command"
+// @Accept json
+// @Produce json
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 502 {object} map[string]string
+// @Router /api/cameras/{name}/control [post]
+func (s *Server) handleCameraControl(w http.ResponseWriter, r *http.Request, cameraName string) {
+	if r.Method != http.MethodPost {
+		respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var cmd camera.Command
+	if err := decodeBody(r, &cmd, "command"); err != nil {
+		respondConfigError(w, err)
+		return
+	}
+
+	err := s.survMgr.ControlCamera(r.Context(), cameraName, cmd)
+	switch {
+	case errors.Is(err, config.ErrCameraNotFound):
+		respondError(w, http.StatusNotFound, err.Error())
+		return
+	case errors.Is(err, camera.ErrInvalidCommand):
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	case err != nil:
+		// The phone rejected the command or couldn't be reached
+		respondError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{
+		"status": "ok",
+		"camera": cameraName,
+		"action": cmd.Action,
+		"value":  cmd.Value,
+	})
+}
+
+// streamMJPEG writes JPEG frames from frameChan as a multipart MJPEG stream
+// until the channel closes or the client disconnects
+func streamMJPEG(w http.ResponseWriter, r *http.Request, frameChan chan []byte) {
 	// Set MJPEG stream headers
 	w.Header().Set("Content-Type", "multipart/x-mixed-replace; boundary=frame")
 	w.Header().Set("Access-Control-Allow-Origin", "*")
@@ -637,6 +1231,9 @@ func (s *Server) handleRecordingDelete(w http.ResponseWriter, r *http.Request) {
 		respondError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to delete file: %v", err))
 		return
 	}
+	if err := recorder.RemoveMetadata(filePath); err != nil {
+		log.Printf("Failed to delete metadata of %s: %v", filePath, err)
+	}
 
 	respondJSON(w, http.StatusOK, map[string]string{
 		"status": "deleted",
@@ -0,0 +1,129 @@
+// Package state persists runtime camera toggles across restarts.
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// CameraState holds the runtime toggles for a single camera.
+type CameraState struct {
+	Running         bool `json:"running"`
+	MotionDetection bool `json:"motion_detection"`
+}
+
+// Store is a small JSON-backed store of camera runtime state.
+type Store struct {
+	path    string
+	cameras map[string]CameraState
+	mu      sync.RWMutex
+}
+
+// Load reads the state file at path. A missing file yields an empty store.
+func Load(path string) (*Store, error) {
+	s := &Store{
+		path:    path,
+		cameras: make(map[string]CameraState),
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &s.cameras); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Get returns the saved state for a camera and whether one exists
+func (s *Store) Get(name string) (CameraState, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	st, ok := s.cameras[name]
+	return st, ok
+}
+
+// Names returns the cameras with saved state, sorted
+func (s *Store) Names() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	names := make([]string, 0, len(s.cameras))
+	for name := range s.cameras {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Set records the state for a camera and writes the store to disk
+func (s *Store) Set(name string, st CameraState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.cameras[name] = st
+	return s.save()
+}
+
+// Update applies fn to the saved state of a camera (starting from defaults
+// if none exists) and writes the store to disk
+func (s *Store) Update(name string, defaults CameraState, fn func(*CameraState)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st, ok := s.cameras[name]
+	if !ok {
+		st = defaults
+	}
+	fn(&st)
+	s.cameras[name] = st
+	return s.save()
+}
+
+// Delete removes the saved state for a camera
+func (s *Store) Delete(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.cameras[name]; !ok {
+		return nil
+	}
+	delete(s.cameras, name)
+	return s.save()
+}
+
+// save writes the store to a temp file and renames it into place so a crash
+// never leaves a truncated state file. Callers must hold the write lock.
+func (s *Store) save() error {
+	if s.path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(s.cameras, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if dir := filepath.Dir(s.path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
@@ -0,0 +1,106 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadMissingFile(t *testing.T) {
+	s, err := Load(filepath.Join(t.TempDir(), "missing.json"))
+	if err != nil {
+		t.Fatalf("Expected a missing file to load, got %v", err)
+	}
+	if names := s.Names(); len(names) != 0 {
+		t.Errorf("Expected an empty store, got %v", names)
+	}
+}
+
+func TestLoadCorruptFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	if err := os.WriteFile(path, []byte("{not json"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Error("Expected a corrupt file to fail loading")
+	}
+}
+
+func TestUpdateStartsFromDefaults(t *testing.T) {
+	s, _ := Load("")
+	defaults := CameraState{Running: true, MotionDetection: true}
+
+	if err := s.Update("porch", defaults, func(st *CameraState) { st.MotionDetection = false }); err != nil {
+		t.Fatal(err)
+	}
+	st, ok := s.Get("porch")
+	if !ok || !st.Running || st.MotionDetection {
+		t.Errorf("Expected running with motion detection off, got %+v %v", st, ok)
+	}
+
+	// A second update starts from the saved state, not the defaults
+	if err := s.Update("porch", defaults, func(st *CameraState) { st.Running = false }); err != nil {
+		t.Fatal(err)
+	}
+	if st, _ := s.Get("porch"); st.Running || st.MotionDetection {
+		t.Errorf("Expected both toggles off, got %+v", st)
+	}
+}
+
+func TestSetDeleteSurviveReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data", "state.json")
+	s, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Set("porch", CameraState{Running: false, MotionDetection: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Set("garage", CameraState{Running: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Delete("garage"); err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if names := reloaded.Names(); len(names) != 1 || names[0] != "porch" {
+		t.Fatalf("Expected only porch after a reload, got %v", names)
+	}
+	if st, _ := reloaded.Get("porch"); st.Running || !st.MotionDetection {
+		t.Errorf("Expected porch stopped with motion detection on, got %+v", st)
+	}
+}
+
+func TestSaveReplacesFileAtomically(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "state.json")
+	if err := os.WriteFile(path, []byte(`{"old": {"running": true}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Set("porch", CameraState{Running: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("Expected the temp file to be renamed into place, got %v", err)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("Expected only the state file in %s, got %d entries", dir, len(entries))
+	}
+	reloaded, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if names := reloaded.Names(); len(names) != 2 {
+		t.Errorf("Expected the existing and new camera, got %v", names)
+	}
+}
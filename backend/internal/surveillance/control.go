@@ -0,0 +1,91 @@
+//go:build opencv
+
+package surveillance
+
+import (
+	"context"
+	"time"
+
+	"github.com/kai5263499/droidcam-sentry/backend/internal/config"
+	"github.com/kai5263499/droidcam-sentry/backend/internal/motion"
+	"github.com/kai5263499/droidcam-sentry/backend/pkg/camera"
+	"github.com/rs/zerolog/log"
+)
+
+// ControlCamera sends a control command to a camera's phone. The camera
+// doesn't have to be running, only configured.
+func (m *Manager) ControlCamera(ctx context.Context, cameraName string, cmd camera.Command) error {
+	if err := cmd.Validate(); err != nil {
+		return err
+	}
+	ctrl, err := m.control(cameraName)
+	if err != nil {
+		return err
+	}
+	if err := ctrl.Do(ctx, cmd); err != nil {
+		return err
+	}
+	log.Info().Str("camera", cameraName).Str("action", cmd.Action).Str("value", cmd.Value).Msg("Camera control command sent")
+	return nil
+}
+
+// control returns the camera's control client, creating a new one when the
+// camera is first controlled or its URL changed
+func (m *Manager) control(cameraName string) (*camera.Control, error) {
+	camCfg, err := m.cfg.Camera(cameraName)
+	if err != nil {
+		return nil, err
+	}
+
+	m.controlsMu.Lock()
+	defer m.controlsMu.Unlock()
+
+	if c, ok := m.controls[cameraName]; ok && c.url == camCfg.URL {
+		return c.Control, nil
+	}
+	timeout := time.Duration(m.cfg.Get().Health.TimeoutSeconds) * time.Second
+	ctrl, err := camera.NewControl(camCfg.URL, timeout)
+	if err != nil {
+		return nil, err
+	}
+	m.controls[cameraName] = cameraControl{Control: ctrl, url: camCfg.URL}
+	return ctrl, nil
+}
+
+// removeControl forgets a removed camera's control client
+func (m *Manager) removeControl(cameraName string) {
+	m.controlsMu.Lock()
+	defer m.controlsMu.Unlock()
+	delete(m.controls, cameraName)
+}
+
+// cameraControl is a control client and the URL it was created for
+type cameraControl struct {
+	*camera.Control
+	url string
+}
+
+// applyControlRules runs the camera's control rules for a motion event
+// transition. The commands are sent in the background so a slow phone
+// doesn't hold up the monitor loop.
+func (m *Manager) applyControlRules(monitor *CameraMonitor, camCfg config.CameraConfig, event *motion.Event) {
+	trigger := config.TriggerMotionStart
+	if event.Type == motion.EventEnd {
+		trigger = config.TriggerMotionEnd
+	}
+	rules := camCfg.Control.Matching(trigger, time.Now())
+	if len(rules) == 0 {
+		return
+	}
+
+	go func() {
+		for _, rule := range rules {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Duration(m.cfg.Get().Health.TimeoutSeconds)*time.Second)
+			err := m.ControlCamera(ctx, monitor.Name, rule.Command())
+			cancel()
+			if err != nil {
+				log.Error().Str("camera", monitor.Name).Str("trigger", trigger).Str("action", rule.Action).Err(err).Msg("Control rule failed")
+			}
+		}
+	}()
+}
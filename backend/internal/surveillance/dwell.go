@@ -0,0 +1,70 @@
+//go:build opencv
+
+package surveillance
+
+import (
+	"fmt"
+
+	"github.com/kai5263499/droidcam-sentry/backend/internal/config"
+	"github.com/kai5263499/droidcam-sentry/backend/internal/motion"
+	"github.com/kai5263499/droidcam-sentry/backend/internal/notify"
+	"github.com/rs/zerolog/log"
+	"gocv.io/x/gocv"
+)
+
+// handleDwells notifies about zone dwell rules that fired, with a snapshot
+// of the frame. It reports whether one of them should start a recording.
+func (m *Manager) handleDwells(monitor *CameraMonitor, camCfg config.CameraConfig, detection *motion.Detection) bool {
+	snapshot := encodeSnapshot(detection.Frame)
+
+	record := false
+	for _, dw := range detection.Dwells {
+		log.Info().Str("camera", monitor.Name).Str("zone", dw.Zone).Int("track", dw.TrackID).
+			Dur("duration", dw.Duration).Msg("Dwell rule fired")
+
+		for _, z := range camCfg.Zones {
+			if z.Name == dw.Zone && z.Dwell != nil && z.Dwell.Record {
+				record = true
+			}
+		}
+
+		what := "Object"
+		var labels []string
+		if dw.Label != "" {
+			what = dw.Label
+			labels = []string{dw.Label}
+		}
+		data := map[string]interface{}{"dwell": dw}
+		if snapshot != nil {
+			data["snapshot"] = snapshot
+		}
+		m.notifier.Send(notify.Event{
+			Type:    notify.EventDwell,
+			Camera:  monitor.Name,
+			Time:    dw.Time,
+			Message: fmt.Sprintf("%s stayed in %s for %s on %s", what, dw.Zone, dw.Duration.Round(1e9), monitor.Name),
+			Labels:  labels,
+			Data:    data,
+		})
+	}
+
+	last := detection.Dwells[len(detection.Dwells)-1]
+	monitor.mu.Lock()
+	monitor.lastDwell = &last
+	monitor.mu.Unlock()
+
+	return record
+}
+
+// encodeSnapshot returns frame as JPEG, or nil if it can't be encoded
+func encodeSnapshot(frame gocv.Mat) []byte {
+	if frame.Empty() {
+		return nil
+	}
+	buf, err := gocv.IMEncode(".jpg", frame)
+	if err != nil {
+		return nil
+	}
+	defer buf.Close()
+	return append([]byte(nil), buf.GetBytes()...)
+}
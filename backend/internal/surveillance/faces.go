@@ -0,0 +1,60 @@
+//go:build opencv
+
+package surveillance
+
+import (
+	"reflect"
+	"time"
+
+	"github.com/kai5263499/droidcam-sentry/backend/internal/config"
+	"github.com/kai5263499/droidcam-sentry/backend/internal/faces"
+	"github.com/rs/zerolog/log"
+	"gocv.io/x/gocv"
+)
+
+// loadFaces (re)loads the shared face detector when its configuration
+// changed. A model that fails to load leaves face detection off.
+func (m *Manager) loadFaces(cfg config.FacesConfig) {
+	m.facesMu.Lock()
+	defer m.facesMu.Unlock()
+
+	if m.faces != nil {
+		if reflect.DeepEqual(m.faces.Config(), cfg) {
+			return
+		}
+		m.faces.Close()
+		m.faces = nil
+	}
+	if !cfg.Enabled {
+		return
+	}
+
+	det, err := faces.New(cfg)
+	if err != nil {
+		log.Error().Str("model", cfg.Model).Err(err).Msg("Failed to load face detection model")
+		return
+	}
+	m.faces = det
+	log.Info().Str("model", cfg.Model).Str("type", cfg.Type).Msg("Face detection model loaded")
+}
+
+// markFaces checks a frame being recorded for faces every interval_ms and
+// marks the recording where they appear
+func (m *Manager) markFaces(monitor *CameraMonitor, frame gocv.Mat) {
+	m.facesMu.RLock()
+	det := m.faces
+	m.facesMu.RUnlock()
+	if det == nil || !monitor.recorder.IsRecording() {
+		return
+	}
+
+	interval := time.Duration(det.Config().IntervalMs) * time.Millisecond
+	if time.Since(monitor.faceCheckedAt) < interval {
+		return
+	}
+	monitor.faceCheckedAt = time.Now()
+
+	if len(det.Detect(frame)) > 0 {
+		monitor.recorder.MarkFaces(interval)
+	}
+}
@@ -0,0 +1,107 @@
+//go:build opencv
+
+package surveillance
+
+import (
+	"path/filepath"
+	"time"
+
+	"github.com/kai5263499/droidcam-sentry/backend/internal/heatmap"
+	"github.com/rs/zerolog/log"
+	"gocv.io/x/gocv"
+)
+
+const (
+	// heatmapSaveInterval is how often heatmaps are persisted
+	heatmapSaveInterval = 5 * time.Minute
+	// referenceInterval is how often the heatmap reference snapshot is
+	// refreshed from the live stream
+	referenceInterval = time.Minute
+)
+
+// Heatmap returns the motion heatmap of a configured camera, loading it from
+// disk on first use
+func (m *Manager) Heatmap(cameraName string) (*heatmap.Heatmap, error) {
+	if _, err := m.cfg.Camera(cameraName); err != nil {
+		return nil, err
+	}
+
+	m.heatmapMu.Lock()
+	defer m.heatmapMu.Unlock()
+
+	if h, ok := m.heatmaps[cameraName]; ok {
+		return h, nil
+	}
+
+	storage := m.cfg.Get().Storage
+	path := filepath.Join(m.cfg.StoragePath(storage.HeatmapDir), cameraName+".heatmap")
+	h, err := heatmap.Load(path, time.Duration(storage.HeatmapDays)*24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	m.heatmaps[cameraName] = h
+	return h, nil
+}
+
+// removeHeatmap deletes the heatmap of a camera removed from the
+// configuration
+func (m *Manager) removeHeatmap(cameraName string) {
+	m.heatmapMu.Lock()
+	h, ok := m.heatmaps[cameraName]
+	delete(m.heatmaps, cameraName)
+	m.heatmapMu.Unlock()
+
+	if !ok {
+		storage := m.cfg.Get().Storage
+		h, _ = heatmap.Load(filepath.Join(m.cfg.StoragePath(storage.HeatmapDir), cameraName+".heatmap"), 0)
+	}
+	if h == nil {
+		return
+	}
+	if err := h.Remove(); err != nil {
+		log.Error().Str("camera", cameraName).Err(err).Msg("Failed to delete heatmap")
+	}
+}
+
+// saveHeatmaps persists every loaded heatmap that changed
+func (m *Manager) saveHeatmaps() {
+	m.heatmapMu.Lock()
+	heatmaps := make(map[string]*heatmap.Heatmap, len(m.heatmaps))
+	for name, h := range m.heatmaps {
+		heatmaps[name] = h
+	}
+	m.heatmapMu.Unlock()
+
+	now := time.Now()
+	for name, h := range heatmaps {
+		if err := h.Save(now); err != nil {
+			log.Error().Str("camera", name).Err(err).Msg("Failed to save heatmap")
+		}
+	}
+}
+
+// runHeatmapSaver persists heatmaps periodically
+func (m *Manager) runHeatmapSaver() {
+	ticker := time.NewTicker(heatmapSaveInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		m.saveHeatmaps()
+	}
+}
+
+// updateReference refreshes the snapshot the camera's heatmap is rendered
+// over, at most once per referenceInterval
+func (m *Manager) updateReference(monitor *CameraMonitor, frame gocv.Mat) {
+	if monitor.detector.Heatmap == nil || frame.Empty() || time.Since(monitor.referenceAt) < referenceInterval {
+		return
+	}
+	monitor.referenceAt = time.Now()
+
+	buf, err := gocv.IMEncode(".jpg", frame)
+	if err != nil {
+		return
+	}
+	defer buf.Close()
+	monitor.detector.Heatmap.SetReference(buf.GetBytes())
+}
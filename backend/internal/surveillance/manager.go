@@ -5,19 +5,24 @@ package surveillance
 import (
 	"fmt"
 
-	"os"
-	"os/exec"
-	"path/filepath"
-	"strconv"
+	"reflect"
+	"slices"
 	"strings"
 	"sync"
 	"syscall"
 	"time"
 
 	"github.com/kai5263499/droidcam-sentry/backend/internal/config"
+	"github.com/kai5263499/droidcam-sentry/backend/internal/counters"
+	"github.com/kai5263499/droidcam-sentry/backend/internal/faces"
 	"github.com/kai5263499/droidcam-sentry/backend/internal/health"
+	"github.com/kai5263499/droidcam-sentry/backend/internal/heatmap"
 	"github.com/kai5263499/droidcam-sentry/backend/internal/motion"
+	"github.com/kai5263499/droidcam-sentry/backend/internal/notify"
+	"github.com/kai5263499/droidcam-sentry/backend/internal/objects"
 	"github.com/kai5263499/droidcam-sentry/backend/internal/recorder"
+	"github.com/kai5263499/droidcam-sentry/backend/internal/state"
+	"github.com/kai5263499/droidcam-sentry/backend/internal/tamper"
 	"github.com/kai5263499/droidcam-sentry/backend/pkg/camera"
 	"github.com/rs/zerolog/log"
 	"gocv.io/x/gocv"
@@ -31,23 +36,70 @@ type Manager struct {
 	cacheMu       sync.RWMutex
 	healthChecker *health.Checker
 	healthCache   map[string]health.CheckResult
+	phones        map[string]*health.Phone
 	healthMu      sync.RWMutex
+	state         *state.Store
+	heatmaps      map[string]*heatmap.Heatmap
+	heatmapMu     sync.Mutex
+	// objects is the shared object detector, nil when disabled
+	objects   *objects.Detector
+	objectsMu sync.RWMutex
+	// faces is the shared face detector, nil when disabled
+	faces    *faces.Detector
+	facesMu  sync.RWMutex
+	notifier *notify.Notifier
+	counters *counters.Store
+	// cameraNames are the cameras in the configuration last applied; m.mu
+	// guards them
+	cameraNames map[string]bool
+	// controls are the DroidCam control clients by camera name
+	controls   map[string]cameraControl
+	controlsMu sync.Mutex
 }
 
 type CameraMonitor struct {
 	Name                string
 	Enabled             bool
 	MotionDetectEnabled bool
+	config              config.CameraConfig
 	stream              *camera.Stream
 	detector            *motion.Detector
 	recorder            *recorder.VideoRecorder
 	stopChan            chan struct{}
 	running             bool
 	subscribers         []chan []byte
-	mu                  sync.RWMutex
+	// debugSubscribers receive JPEG debug views keyed by view name and
+	// scoreSubscribers the outcome of every analysed frame
+	debugSubscribers map[string][]chan []byte
+	scoreSubscribers []chan motion.Sample
+	// lastEvent is the most recent motion event transition
+	lastEvent *motion.Event
+	// lastDwell is the most recent dwell rule that fired
+	lastDwell *motion.DwellEvent
+	// lastStationary is the most recent object that appeared or was removed
+	lastStationary *motion.StationaryEvent
+	// lastObjects are the most recently detected objects
+	lastObjects []objects.Object
+	// event tracks object detection for the current motion event; only
+	// the monitor loop uses it
+	event eventState
+	// faceCheckedAt is when the recording was last checked for faces; only
+	// the monitor loop uses it
+	faceCheckedAt time.Time
+	// referenceAt is when the heatmap reference snapshot was last taken;
+	// only the monitor loop uses it
+	referenceAt time.Time
+	// tamper checks the frames for tampering; tamperAt is when it last ran
+	// and is only used by the monitor loop
+	tamper   *tamper.Checker
+	tamperAt time.Time
+	mu       sync.RWMutex
 }
 
-func NewManager(cfg *config.Config) *Manager {
+// defaultCameraState is used for cameras that have no saved runtime state
+var defaultCameraState = state.CameraState{Running: true, MotionDetection: true}
+
+func NewManager(cfg *config.Config, store *state.Store) *Manager {
 	log.Info().Int("health_check_interval", cfg.Health.CheckIntervalSeconds).Int("health_timeout", cfg.Health.TimeoutSeconds).Msg("Health config loaded")
 	mgr := &Manager{
 		cfg:           cfg,
@@ -55,7 +107,19 @@ func NewManager(cfg *config.Config) *Manager {
 		durationCache: make(map[string]string),
 		healthChecker: health.NewChecker(time.Duration(cfg.Health.TimeoutSeconds) * time.Second),
 		healthCache:   make(map[string]health.CheckResult),
+		phones:        make(map[string]*health.Phone),
+		state:         store,
+		heatmaps:      make(map[string]*heatmap.Heatmap),
+		controls:      make(map[string]cameraControl),
+		notifier:      notify.New(cfg.Get().Notifications),
+		counters:      loadCounters(cfg.StoragePath(cfg.Get().Storage.CountersFile)),
+		cameraNames:   make(map[string]bool),
+	}
+	for _, camCfg := range cfg.Get().Cameras {
+		mgr.cameraNames[camCfg.Name] = true
 	}
+	mgr.loadObjects(cfg.Get().Objects)
+	mgr.loadFaces(cfg.Get().Faces)
 
 	cfg.Subscribe(mgr.onConfigChange)
 
@@ -65,6 +129,9 @@ func NewManager(cfg *config.Config) *Manager {
 	// Start background health checker
 	go mgr.runHealthChecks()
 
+	// Persist motion heatmaps periodically
+	go mgr.runHeatmapSaver()
+
 	return mgr
 }
 
@@ -74,11 +141,20 @@ func (m *Manager) Start() error {
 
 	cfg := m.cfg.Get()
 	for _, camCfg := range cfg.Cameras {
-		if camCfg.Enabled {
-			if err := m.startMonitor(camCfg); err != nil {
-				log.Error().Str("camera", camCfg.Name).Err(err).Msg("Failed to start monitor")
-				continue
-			}
+		if !camCfg.Enabled {
+			continue
+		}
+
+		// Restore runtime toggles saved before the last shutdown
+		st := m.cameraState(camCfg.Name)
+		if !st.Running {
+			log.Info().Str("camera", camCfg.Name).Msg("Camera was stopped before restart, leaving it stopped")
+			continue
+		}
+
+		if err := m.startMonitor(camCfg, st.MotionDetection); err != nil {
+			log.Error().Str("camera", camCfg.Name).Err(err).Msg("Failed to start monitor")
+			continue
 		}
 	}
 
@@ -93,6 +169,8 @@ func (m *Manager) Stop() {
 		log.Info().Str("monitor", name).Msg("Stopping monitor")
 		m.stopMonitor(monitor)
 	}
+
+	m.saveHeatmaps()
 }
 
 // StartCamera starts monitoring for a specific camera
@@ -119,7 +197,15 @@ func (m *Manager) StartCamera(cameraName string) error {
 		return fmt.Errorf("camera %s not found in configuration", cameraName)
 	}
 
-	return m.startMonitor(*camCfg)
+	st := m.cameraState(cameraName)
+	if err := m.startMonitor(*camCfg, st.MotionDetection); err != nil {
+		return err
+	}
+
+	m.saveCameraState(cameraName, func(st *state.CameraState) {
+		st.Running = true
+	})
+	return nil
 }
 
 // StopCamera stops monitoring for a specific camera
@@ -139,6 +225,10 @@ func (m *Manager) StopCamera(cameraName string) error {
 	m.stopMonitor(monitor)
 	delete(m.monitors, cameraName)
 
+	m.saveCameraState(cameraName, func(st *state.CameraState) {
+		st.Running = false
+	})
+
 	log.Info().Str("camera", cameraName).Msg("Camera stopped")
 	return nil
 }
@@ -161,6 +251,10 @@ func (m *Manager) EnableMotionDetection(cameraName string) error {
 	monitor.MotionDetectEnabled = true
 	monitor.mu.Unlock()
 
+	m.saveCameraState(cameraName, func(st *state.CameraState) {
+		st.MotionDetection = true
+	})
+
 	log.Info().Str("camera", cameraName).Msg("Motion detection enabled")
 	return nil
 }
@@ -183,6 +277,10 @@ func (m *Manager) DisableMotionDetection(cameraName string) error {
 	monitor.MotionDetectEnabled = false
 	monitor.mu.Unlock()
 
+	m.saveCameraState(cameraName, func(st *state.CameraState) {
+		st.MotionDetection = false
+	})
+
 	// Stop any active recording
 	if monitor.recorder.IsRecording() {
 		monitor.recorder.Stop()
@@ -192,22 +290,79 @@ func (m *Manager) DisableMotionDetection(cameraName string) error {
 	return nil
 }
 
-func (m *Manager) startMonitor(camCfg config.CameraConfig) error {
+// cameraState returns the saved runtime state for a camera, or the defaults
+func (m *Manager) cameraState(name string) state.CameraState {
+	if m.state == nil {
+		return defaultCameraState
+	}
+	if st, ok := m.state.Get(name); ok {
+		return st
+	}
+	return defaultCameraState
+}
+
+// saveCameraState persists a runtime toggle change for a camera
+func (m *Manager) saveCameraState(name string, fn func(*state.CameraState)) {
+	if m.state == nil {
+		return
+	}
+	if err := m.state.Update(name, defaultCameraState, fn); err != nil {
+		log.Error().Str("camera", name).Err(err).Msg("Failed to save camera state")
+	}
+}
+
+// migrateThreshold converts a camera's pixel threshold to a percentage of its
+// frame or zone area at the measured stream resolution and saves the result.
+// The returned config is what the monitor should run with.
+func (m *Manager) migrateThreshold(camCfg config.CameraConfig, info camera.StreamInfo) config.CameraConfig {
+	if info.Width <= 0 || info.Height <= 0 {
+		return camCfg
+	}
+
+	migrated := camCfg
+	if !migrated.MigrateThreshold(info.Width, info.Height) {
+		return camCfg
+	}
+
+	if _, err := m.cfg.ReplaceCamera(camCfg.Name, migrated, false); err != nil {
+		log.Error().Str("camera", camCfg.Name).Err(err).Msg("Failed to migrate motion threshold")
+		return camCfg
+	}
+	if err := m.cfg.Save("threshold migration"); err != nil {
+		log.Error().Str("camera", camCfg.Name).Err(err).Msg("Failed to save migrated motion threshold")
+	}
+
+	log.Info().
+		Str("camera", camCfg.Name).
+		Str("resolution", info.Resolution).
+		Float64("pixels", camCfg.MotionThreshold).
+		Float64("percent", migrated.MotionThresholdPercent).
+		Msg("Migrated motion threshold to percent of frame area")
+	return migrated
+}
+
+func (m *Manager) startMonitor(camCfg config.CameraConfig, motionEnabled bool) error {
 	log.Info().Str("camera", camCfg.Name).Str("url", camCfg.URL).Msg("Starting monitor")
 
 	cfg := m.cfg.Get()
 
 	stream := camera.NewStream(camCfg.Name, camCfg.URL)
+	stream.ConfigureStall(time.Duration(cfg.Health.StallSeconds)*time.Second, cfg.Health.StallMaxDiff)
 	if err := stream.Open(); err != nil {
 		return err
 	}
 
-	detector := motion.NewDetector(
-		camCfg.Name,
-		camCfg.MotionThreshold,
-		cfg.Motion.MinArea,
-		cfg.Motion.DetectionIntervalMs,
-	)
+	// Convert a pixel threshold to a percentage now that the resolution is known
+	if cfg.EffectiveMotion(camCfg).ThresholdUnit == config.ThresholdPercent {
+		camCfg = m.migrateThreshold(camCfg, stream.GetInfo())
+	}
+
+	detector := motion.NewDetector(camCfg.Name, motion.SettingsFromConfig(camCfg, cfg.Motion))
+	if hm, err := m.Heatmap(camCfg.Name); err != nil {
+		log.Error().Str("camera", camCfg.Name).Err(err).Msg("Failed to load heatmap")
+	} else {
+		detector.Heatmap = hm
+	}
 
 	rec := recorder.NewRecorder(
 		camCfg.Name,
@@ -220,10 +375,12 @@ func (m *Manager) startMonitor(camCfg config.CameraConfig) error {
 	monitor := &CameraMonitor{
 		Name:                camCfg.Name,
 		Enabled:             true,
-		MotionDetectEnabled: true,
+		MotionDetectEnabled: motionEnabled,
+		config:              camCfg,
 		stream:              stream,
 		detector:            detector,
 		recorder:            rec,
+		tamper:              tamper.NewChecker(cfg.Tamper),
 		stopChan:            make(chan struct{}),
 		running:             true,
 	}
@@ -260,17 +417,96 @@ func (m *Manager) monitorLoop(monitor *CameraMonitor) {
 				continue
 			}
 
+			// Detect motion only if enabled
+			monitor.mu.RLock()
+			motionEnabled := monitor.MotionDetectEnabled
+			camCfg := monitor.config
+			monitor.mu.RUnlock()
+			overlay := camCfg.Overlay
+
+			if motionEnabled {
+				detection, motionDetected := monitor.detector.Detect(frame)
+				if detection != nil && detection.Event != nil {
+					monitor.mu.Lock()
+					monitor.lastEvent = detection.Event
+					monitor.mu.Unlock()
+					m.applyControlRules(monitor, camCfg, detection.Event)
+				}
+
+				// Object detection can hold back recording until an allowed
+				// object shows up, and tripwires can replace motion as the
+				// trigger altogether; dwell rules start recordings of their own
+				confirmed := motionDetected && m.classifyMotion(monitor, camCfg, detection)
+				crossed, dwelled := false, false
+				if detection != nil && len(detection.Crossings) > 0 {
+					crossed = m.handleCrossings(monitor, camCfg, detection.Crossings)
+				}
+				if detection != nil && len(detection.Dwells) > 0 {
+					dwelled = m.handleDwells(monitor, camCfg, detection)
+				}
+				start := confirmed
+				if camCfg.TripwireRecording() {
+					start = crossed
+				}
+				start = start || dwelled
+				if detection != nil && len(detection.Stationary) > 0 {
+					m.handleStationary(monitor, detection.Stationary)
+				}
+
+				if confirmed || crossed || dwelled {
+					// Start recording if not already recording
+					if start && !monitor.recorder.IsRecording() {
+						if err := monitor.recorder.StartRecording(); err != nil {
+							log.Error().Str("camera", monitor.Name).Err(err).Msg("Failed to start recording")
+						} else {
+							m.labelRecording(monitor)
+						}
+					}
+
+					// Reset post-buffer timer
+					monitor.recorder.OnMotion()
+				}
+
+				// Clean up detection frame
+				if detection != nil && !detection.Frame.Empty() {
+					detection.Frame.Close()
+				}
+
+				if sample, ok := monitor.detector.TakeSample(); ok {
+					m.publishAnalysis(monitor, sample)
+				}
+			}
+
+			m.updateReference(monitor, frame)
+			m.checkTamper(monitor, camCfg, frame)
+			m.markFaces(monitor, frame)
+
+			// Draw motion boxes onto a copy of the frame for the outputs that
+			// want them; detection always runs on the clean frame
+			liveFrame, recordFrame := frame, frame
+			annotated := gocv.NewMat()
+			if motionEnabled && (overlay.Live || overlay.Recording) && !frame.Empty() {
+				frame.CopyTo(&annotated)
+				monitor.detector.DrawOverlay(&annotated)
+				if overlay.Live {
+					liveFrame = annotated
+				}
+				if overlay.Recording {
+					recordFrame = annotated
+				}
+			}
+
 			// Add frame to recorder buffer
-			monitor.recorder.AddFrame(frame)
+			monitor.recorder.AddFrame(recordFrame)
 
 			// Broadcast frame to live viewers (clone frame first to avoid race)
 			monitor.mu.RLock()
 			hasSubscribers := len(monitor.subscribers) > 0
 			monitor.mu.RUnlock()
 
-			if hasSubscribers && !frame.Empty() {
+			if hasSubscribers && !liveFrame.Empty() {
 				// Clone frame before encoding to avoid race with frame.Close()
-				clonedFrame := frame.Clone()
+				clonedFrame := liveFrame.Clone()
 				buf, err := gocv.IMEncode(".jpg", clonedFrame)
 				clonedFrame.Close() // Clean up clone immediately
 
@@ -291,35 +527,11 @@ func (m *Manager) monitorLoop(monitor *CameraMonitor) {
 				}
 			}
 
-			// Detect motion only if enabled
-			monitor.mu.RLock()
-			motionEnabled := monitor.MotionDetectEnabled
-			monitor.mu.RUnlock()
-
-			if motionEnabled {
-				detection, motionDetected := monitor.detector.Detect(frame)
-				if motionDetected {
-					// Start recording if not already recording
-					if !monitor.recorder.IsRecording() {
-						if err := monitor.recorder.StartRecording(); err != nil {
-							log.Error().Str("camera", monitor.Name).Err(err).Msg("Failed to start recording")
-						}
-					}
-
-					// Reset post-buffer timer
-					monitor.recorder.OnMotion()
-
-					// Clean up detection frame
-					if detection != nil && !detection.Frame.Empty() {
-						detection.Frame.Close()
-					}
-				}
-			}
-
 			// Update recorder (check if post-buffer expired)
 			monitor.recorder.Update()
 
-			// Clean up frame
+			// Clean up frames
+			annotated.Close()
 			if !frame.Empty() {
 				frame.Close()
 			}
@@ -335,6 +547,24 @@ func (m *Manager) stopMonitor(monitor *CameraMonitor) {
 	close(monitor.stopChan)
 	monitor.running = false
 
+	// End live streams so viewers reconnect to a restarted monitor
+	monitor.mu.Lock()
+	for _, sub := range monitor.subscribers {
+		close(sub)
+	}
+	monitor.subscribers = nil
+	for view, subs := range monitor.debugSubscribers {
+		for _, sub := range subs {
+			close(sub)
+		}
+		delete(monitor.debugSubscribers, view)
+	}
+	for _, sub := range monitor.scoreSubscribers {
+		close(sub)
+	}
+	monitor.scoreSubscribers = nil
+	monitor.mu.Unlock()
+
 	if monitor.stream != nil {
 		monitor.stream.Close()
 	}
@@ -346,9 +576,132 @@ func (m *Manager) stopMonitor(monitor *CameraMonitor) {
 	}
 }
 
-func (m *Manager) onConfigChange(cfg *config.Config) {
+// onConfigChange reconciles running monitors with the updated configuration:
+// removed or disabled cameras are stopped, cameras whose stream or recording
+// settings changed are restarted, motion settings are applied in place and
+// newly added or enabled cameras are started.
+func (m *Manager) onConfigChange(c *config.Config) {
 	log.Info().Msg("Configuration changed, reloading cameras...")
-	// TODO: Handle camera enable/disable, URL changes, etc.
+
+	cfg := c.Get()
+	m.notifier.Configure(cfg.Notifications)
+	m.loadObjects(cfg.Objects)
+	m.loadFaces(cfg.Faces)
+	configured := make(map[string]config.CameraConfig, len(cfg.Cameras))
+	for _, camCfg := range cfg.Cameras {
+		configured[camCfg.Name] = camCfg
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for name, monitor := range m.monitors {
+		camCfg, exists := configured[name]
+		switch {
+		case !exists:
+			log.Info().Str("camera", name).Msg("Camera removed from configuration, stopping")
+			m.stopMonitor(monitor)
+			delete(m.monitors, name)
+
+		case !camCfg.Enabled:
+			log.Info().Str("camera", name).Msg("Camera disabled, stopping")
+			m.stopMonitor(monitor)
+			delete(m.monitors, name)
+
+		case needsRestart(monitor.config, camCfg):
+			log.Info().Str("camera", name).Msg("Camera configuration changed, restarting")
+			monitor.mu.RLock()
+			motionEnabled := monitor.MotionDetectEnabled
+			monitor.mu.RUnlock()
+
+			m.stopMonitor(monitor)
+			delete(m.monitors, name)
+			if err := m.startMonitor(camCfg, motionEnabled); err != nil {
+				log.Error().Str("camera", name).Err(err).Msg("Failed to restart monitor")
+			}
+
+		default:
+			// Motion settings apply to the running detector, so switching
+			// algorithms or thresholds doesn't drop the stream
+			settings := motion.SettingsFromConfig(camCfg, cfg.Motion)
+			if !reflect.DeepEqual(monitor.detector.Settings, settings) {
+				log.Info().Str("camera", name).Str("algorithm", settings.Algorithm).Msg("Motion settings changed, applying")
+				monitor.detector.Configure(settings)
+			}
+			monitor.tamper.Configure(cfg.Tamper)
+			monitor.stream.ConfigureStall(time.Duration(cfg.Health.StallSeconds)*time.Second, cfg.Health.StallMaxDiff)
+			monitor.mu.Lock()
+			monitor.config = camCfg
+			monitor.mu.Unlock()
+		}
+	}
+
+	m.removeCameras(configured)
+
+	for _, camCfg := range cfg.Cameras {
+		if _, running := m.monitors[camCfg.Name]; running || !camCfg.Enabled {
+			continue
+		}
+
+		st := m.cameraState(camCfg.Name)
+		if !st.Running {
+			continue
+		}
+
+		if err := m.startMonitor(camCfg, st.MotionDetection); err != nil {
+			log.Error().Str("camera", camCfg.Name).Err(err).Msg("Failed to start monitor")
+		}
+	}
+}
+
+// removeCameras deletes the saved state, heatmaps, counters and telemetry
+// of cameras that are no longer configured, whether or not they were
+// running. Cameras are found through the previous configuration and the
+// saved state, so stopped cameras and ones removed while the service was
+// down are cleaned up too.
+func (m *Manager) removeCameras(configured map[string]config.CameraConfig) {
+	var removed []string
+	for name := range m.cameraNames {
+		if _, ok := configured[name]; !ok {
+			removed = append(removed, name)
+		}
+	}
+	if m.state != nil {
+		for _, name := range m.state.Names() {
+			if _, ok := configured[name]; !ok && !m.cameraNames[name] {
+				removed = append(removed, name)
+			}
+		}
+	}
+
+	m.cameraNames = make(map[string]bool, len(configured))
+	for name := range configured {
+		m.cameraNames[name] = true
+	}
+
+	for _, name := range removed {
+		log.Info().Str("camera", name).Msg("Deleting data of removed camera")
+		m.removeHeatmap(name)
+		m.removeControl(name)
+		if err := m.counters.Delete(name); err != nil {
+			log.Error().Str("camera", name).Err(err).Msg("Failed to delete crossing counters")
+		}
+		if m.state != nil {
+			if err := m.state.Delete(name); err != nil {
+				log.Error().Str("camera", name).Err(err).Msg("Failed to delete camera state")
+			}
+		}
+		m.healthMu.Lock()
+		delete(m.phones, name)
+		delete(m.healthCache, name)
+		m.healthMu.Unlock()
+	}
+}
+
+// needsRestart reports whether a camera change affects the stream or the
+// recorder; anything else is applied to the running monitor
+func needsRestart(old, updated config.CameraConfig) bool {
+	return old.URL != updated.URL || !reflect.DeepEqual(old.Recording, updated.Recording)
 }
 
 func (m *Manager) GetStatus() map[string]interface{} {
@@ -372,18 +725,43 @@ func (m *Manager) GetStatus() map[string]interface{} {
 			"is_open":          false,
 			"recording":        false,
 			"motion_detection": false,
+			"saved_state":      m.cameraState(name),
+			"motion_settings":  cfg.EffectiveMotion(camCfg),
 		}
 
 		// If monitor exists and is running, get runtime status
 		if monitorExists {
 			monitor.mu.RLock()
 			motionEnabled := monitor.MotionDetectEnabled
+			lastEvent := monitor.lastEvent
+			lastObjects := monitor.lastObjects
+			lastDwell := monitor.lastDwell
+			lastStationary := monitor.lastStationary
 			monitor.mu.RUnlock()
 
 			camStatus["running"] = monitor.running
 			camStatus["is_open"] = monitor.stream.IsOpen()
 			camStatus["recording"] = monitor.recorder.IsRecording()
 			camStatus["motion_detection"] = motionEnabled
+			if lastEvent != nil {
+				camStatus["last_motion_event"] = lastEvent
+			}
+			if lastDwell != nil {
+				camStatus["last_dwell_event"] = lastDwell
+			}
+			if lastStationary != nil {
+				camStatus["last_stationary_event"] = lastStationary
+			}
+			if len(lastObjects) > 0 {
+				camStatus["last_objects"] = lastObjects
+			}
+			camStatus["lighting_suppressed"] = monitor.detector.Suppressed()
+			if camCfg.TamperEnabled(monitor.tamper.Config()) {
+				camStatus["tamper"] = map[string]interface{}{
+					"active":      monitor.tamper.Active(),
+					"measurement": monitor.tamper.Last(),
+				}
+			}
 
 			// Add stream info if available
 			if monitor.stream != nil && monitor.stream.IsOpen() {
@@ -398,12 +776,25 @@ func (m *Manager) GetStatus() map[string]interface{} {
 			}
 		}
 
-		// Add health check results (available for all cameras)
+		// Add health check results (available for all cameras) and whether
+		// a running stream is stuck on one frame
 		m.healthMu.RLock()
-		if healthResult, exists := m.healthCache[name]; exists {
+		healthResult, hasHealth := m.healthCache[name]
+		m.healthMu.RUnlock()
+		if monitorExists {
+			stall := monitor.stream.StallStatus()
+			healthResult.Stalled, healthResult.Stalls = stall.Stalled, stall.Count
+			if stall.Stalled {
+				healthResult.StalledSince = stall.Since.Format(time.RFC3339)
+			}
+			hasHealth = true
+		}
+		if hasHealth {
 			camStatus["health"] = healthResult
 		}
-		m.healthMu.RUnlock()
+		if phone := m.phone(name, false); phone != nil {
+			camStatus["telemetry"] = phone.Status()
+		}
 
 		cameras = append(cameras, camStatus)
 	}
@@ -479,6 +870,154 @@ func (m *Manager) Unsubscribe(cameraName string, ch chan []byte) {
 	monitor.mu.Unlock()
 }
 
+// SubscribeDebug subscribes to JPEG frames of one of the detector's debug
+// views (see motion.DebugViews)
+func (m *Manager) SubscribeDebug(cameraName, view string) (chan []byte, error) {
+	if !slices.Contains(motion.DebugViews, view) {
+		return nil, fmt.Errorf("unknown view %q (use one of %s)", view, strings.Join(motion.DebugViews, ", "))
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	monitor, err := m.runningMonitor(cameraName)
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan []byte, 5)
+	monitor.mu.Lock()
+	if monitor.debugSubscribers == nil {
+		monitor.debugSubscribers = make(map[string][]chan []byte)
+	}
+	monitor.debugSubscribers[view] = append(monitor.debugSubscribers[view], ch)
+	monitor.mu.Unlock()
+
+	monitor.detector.SetDebug(true)
+	return ch, nil
+}
+
+// UnsubscribeDebug unsubscribes from a debug view
+func (m *Manager) UnsubscribeDebug(cameraName, view string, ch chan []byte) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	monitor, exists := m.monitors[cameraName]
+	if !exists {
+		return
+	}
+
+	monitor.mu.Lock()
+	subs := monitor.debugSubscribers[view]
+	for i, sub := range subs {
+		if sub == ch {
+			monitor.debugSubscribers[view] = append(subs[:i], subs[i+1:]...)
+			close(ch)
+			break
+		}
+	}
+	watching := false
+	for _, subs := range monitor.debugSubscribers {
+		watching = watching || len(subs) > 0
+	}
+	monitor.mu.Unlock()
+
+	if !watching {
+		monitor.detector.SetDebug(false)
+	}
+}
+
+// SubscribeScores subscribes to the outcome of every analysed frame
+func (m *Manager) SubscribeScores(cameraName string) (chan motion.Sample, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	monitor, err := m.runningMonitor(cameraName)
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan motion.Sample, 20)
+	monitor.mu.Lock()
+	monitor.scoreSubscribers = append(monitor.scoreSubscribers, ch)
+	monitor.mu.Unlock()
+
+	return ch, nil
+}
+
+// UnsubscribeScores unsubscribes from the score feed
+func (m *Manager) UnsubscribeScores(cameraName string, ch chan motion.Sample) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	monitor, exists := m.monitors[cameraName]
+	if !exists {
+		return
+	}
+
+	monitor.mu.Lock()
+	for i, sub := range monitor.scoreSubscribers {
+		if sub == ch {
+			monitor.scoreSubscribers = append(monitor.scoreSubscribers[:i], monitor.scoreSubscribers[i+1:]...)
+			close(ch)
+			break
+		}
+	}
+	monitor.mu.Unlock()
+}
+
+// runningMonitor returns the monitor of a running camera. m.mu must be held.
+func (m *Manager) runningMonitor(cameraName string) (*CameraMonitor, error) {
+	monitor, exists := m.monitors[cameraName]
+	if !exists {
+		return nil, fmt.Errorf("camera %s not found", cameraName)
+	}
+	if !monitor.running {
+		return nil, fmt.Errorf("camera %s is not running", cameraName)
+	}
+	return monitor, nil
+}
+
+// publishAnalysis sends an analysed frame's score and debug views to their
+// subscribers, skipping any that are behind
+func (m *Manager) publishAnalysis(monitor *CameraMonitor, sample motion.Sample) {
+	monitor.mu.RLock()
+	defer monitor.mu.RUnlock()
+
+	for _, sub := range monitor.scoreSubscribers {
+		select {
+		case sub <- sample:
+		default:
+		}
+	}
+
+	for view, subs := range monitor.debugSubscribers {
+		if len(subs) == 0 {
+			continue
+		}
+
+		img := monitor.detector.DebugView(view)
+		if img.Empty() {
+			img.Close()
+			continue
+		}
+		buf, err := gocv.IMEncode(".jpg", img)
+		img.Close()
+		if err != nil {
+			continue
+		}
+
+		frameBytes := buf.GetBytes()
+		buf.Close()
+		for _, sub := range subs {
+			select {
+			case sub <- frameBytes:
+			default:
+			}
+		}
+	}
+}
+
 // scanVideoDurations runs in background to probe video durations
 func (m *Manager) scanVideoDurations() {
 	ticker := time.NewTicker(30 * time.Second)
@@ -497,64 +1036,28 @@ func (m *Manager) updateDurations() {
 	cfg := m.cfg.Get()
 
 	for _, camCfg := range cfg.Cameras {
-		recordingPath := camCfg.Recording.Path
-		if _, err := os.Stat(recordingPath); os.IsNotExist(err) {
-			continue
-		}
-
-		filepath.Walk(recordingPath, func(path string, info os.FileInfo, err error) error {
-			if err != nil || info.IsDir() {
-				return nil
-			}
-
-			if !strings.HasSuffix(strings.ToLower(info.Name()), ".mp4") {
-				return nil
-			}
-
+		recordings, _ := recorder.List(camCfg.Name, camCfg.Recording.Path)
+		for _, rec := range recordings {
 			// Check if we already have duration cached
 			m.cacheMu.RLock()
-			_, exists := m.durationCache[path]
+			_, exists := m.durationCache[rec.Path]
 			m.cacheMu.RUnlock()
 
 			if exists {
-				return nil
+				continue
 			}
 
 			// Probe video duration using ffprobe
-			duration := m.probeDuration(path)
+			duration := "Unknown"
+			if d, err := recorder.ProbeDuration(rec.Path); err == nil {
+				duration = recorder.FormatDuration(d)
+			}
 
 			m.cacheMu.Lock()
-			m.durationCache[path] = duration
+			m.durationCache[rec.Path] = duration
 			m.cacheMu.Unlock()
-
-			return nil
-		})
-	}
-}
-
-// probeDuration uses ffprobe to get video duration
-func (m *Manager) probeDuration(filepath string) string {
-	cmd := exec.Command("ffprobe",
-		"-v", "error",
-		"-show_entries", "format=duration",
-		"-of", "default=noprint_wrappers=1:nokey=1",
-		filepath)
-
-	output, err := cmd.Output()
-	if err != nil {
-		return "Unknown"
-	}
-
-	durationStr := strings.TrimSpace(string(output))
-	seconds, err := strconv.ParseFloat(durationStr, 64)
-	if err != nil {
-		return "Unknown"
+		}
 	}
-
-	// Format as MM:SS
-	mins := int(seconds) / 60
-	secs := int(seconds) % 60
-	return fmt.Sprintf("%d:%02d", mins, secs)
 }
 
 func (m *Manager) GetRecordings() []map[string]interface{} {
@@ -563,44 +1066,30 @@ func (m *Manager) GetRecordings() []map[string]interface{} {
 
 	// Scan all camera recording directories
 	for _, camCfg := range cfg.Cameras {
-		recordingPath := camCfg.Recording.Path
-
-		// Check if directory exists
-		if _, err := os.Stat(recordingPath); os.IsNotExist(err) {
-			continue
-		}
-
-		// Walk directory and find MP4 files
-		filepath.Walk(recordingPath, func(path string, info os.FileInfo, err error) error {
-			if err != nil {
-				return nil
-			}
+		list, _ := recorder.List(camCfg.Name, camCfg.Recording.Path)
+		for _, rec := range list {
+			sizeMB := float64(rec.Size) / (1024 * 1024)
 
-			// Only include MP4 files
-			if !info.IsDir() && strings.HasSuffix(strings.ToLower(info.Name()), ".mp4") {
-				sizeMB := float64(info.Size()) / (1024 * 1024)
-
-				// Get duration from cache
-				m.cacheMu.RLock()
-				duration, exists := m.durationCache[path]
-				m.cacheMu.RUnlock()
-
-				if !exists {
-					duration = "Unknown"
-				}
+			// Get duration from cache
+			m.cacheMu.RLock()
+			duration, exists := m.durationCache[rec.Path]
+			m.cacheMu.RUnlock()
 
-				recordings = append(recordings, map[string]interface{}{
-					"name":      info.Name(),
-					"path":      path,
-					"size":      fmt.Sprintf("%.2f MB", sizeMB),
-					"timestamp": info.ModTime().Format("2006-01-02 15:04:05"),
-					"camera":    camCfg.Name,
-					"duration":  duration,
-				})
+			if !exists {
+				duration = "Unknown"
 			}
 
-			return nil
-		})
+			recordings = append(recordings, map[string]interface{}{
+				"name":      rec.Name,
+				"path":      rec.Path,
+				"size":      fmt.Sprintf("%.2f MB", sizeMB),
+				"timestamp": rec.ModTime.Format("2006-01-02 15:04:05"),
+				"camera":    camCfg.Name,
+				"duration":  duration,
+				"labels":    rec.Labels,
+				"faces":     rec.Faces,
+			})
+		}
 	}
 
 	return recordings
@@ -612,21 +1101,10 @@ func (m *Manager) calculateRecordingsSize() float64 {
 	var totalBytes int64
 
 	for _, camCfg := range cfg.Cameras {
-		recordingPath := camCfg.Recording.Path
-		if _, err := os.Stat(recordingPath); os.IsNotExist(err) {
-			continue
+		recordings, _ := recorder.List(camCfg.Name, camCfg.Recording.Path)
+		for _, rec := range recordings {
+			totalBytes += rec.Size
 		}
-
-		filepath.Walk(recordingPath, func(path string, info os.FileInfo, err error) error {
-			if err != nil || info.IsDir() {
-				return nil
-			}
-
-			if strings.HasSuffix(strings.ToLower(info.Name()), ".mp4") {
-				totalBytes += info.Size()
-			}
-			return nil
-		})
 	}
 
 	return float64(totalBytes) / (1024 * 1024 * 1024) // Convert to GB
@@ -662,5 +1140,9 @@ func (m *Manager) performHealthChecks() {
 			Bool("url_accessible", result.URLAccessible).
 			Int64("response_time_ms", result.ResponseTime).
 			Msg("Health check")
+
+		if result.HostReachable {
+			m.pollTelemetry(camCfg, cfg.Health)
+		}
 	}
 }
@@ -0,0 +1,176 @@
+//go:build opencv
+
+package surveillance
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/kai5263499/droidcam-sentry/backend/internal/config"
+	"github.com/kai5263499/droidcam-sentry/backend/internal/motion"
+	"github.com/kai5263499/droidcam-sentry/backend/internal/notify"
+	"github.com/kai5263499/droidcam-sentry/backend/internal/objects"
+	"github.com/rs/zerolog/log"
+)
+
+// eventState tracks object detection during one motion event. Only the
+// monitor loop uses it.
+type eventState struct {
+	// confirmed is set once the event may be recorded and notified once a
+	// notification went out
+	confirmed bool
+	notified  bool
+	// seen holds the best detection of each label so far
+	seen      map[string]objects.Object
+	checkedAt time.Time
+}
+
+// loadObjects (re)loads the shared object detector when its configuration
+// changed. A model that fails to load leaves object detection off.
+func (m *Manager) loadObjects(cfg config.ObjectsConfig) {
+	m.objectsMu.Lock()
+	defer m.objectsMu.Unlock()
+
+	if m.objects != nil {
+		if reflect.DeepEqual(m.objects.Config(), cfg) {
+			return
+		}
+		m.objects.Close()
+		m.objects = nil
+	}
+	if !cfg.Enabled {
+		return
+	}
+
+	det, err := objects.New(cfg)
+	if err != nil {
+		log.Error().Str("model", cfg.Model).Err(err).Msg("Failed to load object detection model")
+		return
+	}
+	m.objects = det
+	log.Info().Str("model", cfg.Model).Str("type", cfg.Type).Msg("Object detection model loaded")
+}
+
+// objectDetector returns the shared object detector, or nil when object
+// detection is off
+func (m *Manager) objectDetector() *objects.Detector {
+	m.objectsMu.RLock()
+	defer m.objectsMu.RUnlock()
+	return m.objects
+}
+
+// classifyMotion runs object detection on the analysed frames of an active
+// motion event and sends its notification. It reports whether the event may
+// be recorded: always without object detection or a record allow-list,
+// otherwise once an allowed object has been seen.
+func (m *Manager) classifyMotion(monitor *CameraMonitor, camCfg config.CameraConfig, detection *motion.Detection) bool {
+	det := m.objectDetector()
+	ev := &monitor.event
+
+	if detection != nil && detection.Event != nil && detection.Event.Type == motion.EventStart {
+		*ev = eventState{
+			confirmed: len(camCfg.Objects.Record) == 0,
+			seen:      make(map[string]objects.Object),
+		}
+		// Tripwires with notify set replace motion notifications
+		ev.notified = camCfg.TripwireNotifications()
+		if !ev.notified && (det == nil || len(camCfg.Objects.Notify) == 0) {
+			m.notifyMotion(monitor.Name, detection.Event, nil)
+			ev.notified = true
+		}
+	}
+	if det == nil {
+		return true
+	}
+	if detection == nil || detection.Frame.Empty() {
+		return ev.confirmed
+	}
+
+	interval := time.Duration(det.Config().IntervalMs) * time.Millisecond
+	if time.Since(ev.checkedAt) < interval {
+		return ev.confirmed
+	}
+	ev.checkedAt = time.Now()
+
+	boxes := make([]image.Rectangle, len(detection.Regions))
+	for i, r := range detection.Regions {
+		boxes[i] = r.Box
+	}
+	found, err := det.Detect(detection.Frame, boxes)
+	if errors.Is(err, objects.ErrClosed) {
+		// The detector was swapped by a configuration reload
+		return ev.confirmed
+	}
+	if err != nil {
+		log.Error().Str("camera", monitor.Name).Err(err).Msg("Object detection failed")
+		return ev.confirmed
+	}
+	if len(found) == 0 {
+		return ev.confirmed
+	}
+
+	if ev.seen == nil {
+		ev.seen = make(map[string]objects.Object)
+	}
+	for _, o := range found {
+		if best, ok := ev.seen[o.Label]; !ok || o.Confidence > best.Confidence {
+			ev.seen[o.Label] = o
+		}
+		monitor.recorder.Label(o.Label, o.Confidence)
+		monitor.detector.LabelTrack(o.Box, o.Label)
+	}
+	monitor.mu.Lock()
+	monitor.lastObjects = found
+	monitor.mu.Unlock()
+
+	if !ev.confirmed && len(objects.Allowed(found, camCfg.Objects.Record)) > 0 {
+		ev.confirmed = true
+		log.Info().Str("camera", monitor.Name).Strs("labels", objects.Labels(found)).Msg("Motion confirmed by object detection")
+	}
+	if !ev.notified {
+		if allowed := objects.Allowed(found, camCfg.Objects.Notify); len(allowed) > 0 {
+			m.notifyMotion(monitor.Name, detection.Event, allowed)
+			ev.notified = true
+		}
+	}
+	return ev.confirmed
+}
+
+// labelRecording tags a recording that just started with the objects seen
+// earlier in the event
+func (m *Manager) labelRecording(monitor *CameraMonitor) {
+	for _, o := range monitor.event.seen {
+		monitor.recorder.Label(o.Label, o.Confidence)
+	}
+}
+
+// notifyMotion sends a motion notification, naming the detected objects if
+// there are any
+func (m *Manager) notifyMotion(cameraName string, event *motion.Event, found []objects.Object) {
+	labels := objects.Labels(found)
+	message := fmt.Sprintf("Motion detected on %s", cameraName)
+	if len(labels) > 0 {
+		message = fmt.Sprintf("%s detected on %s", strings.Join(labels, ", "), cameraName)
+	}
+
+	data := map[string]interface{}{}
+	if event != nil {
+		data["event"] = event
+	}
+	if len(found) > 0 {
+		data["objects"] = found
+	}
+
+	m.notifier.Send(notify.Event{
+		Type:    notify.EventMotion,
+		Camera:  cameraName,
+		Time:    time.Now(),
+		Message: message,
+		Labels:  labels,
+		Data:    data,
+	})
+}
@@ -0,0 +1,49 @@
+//go:build opencv
+
+package surveillance
+
+import (
+	"fmt"
+
+	"github.com/kai5263499/droidcam-sentry/backend/internal/motion"
+	"github.com/kai5263499/droidcam-sentry/backend/internal/notify"
+	"github.com/rs/zerolog/log"
+)
+
+// handleStationary notifies about objects that were left behind or taken
+// away, with before and after crops of the region
+func (m *Manager) handleStationary(monitor *CameraMonitor, events []motion.StationaryEvent) {
+	for _, ev := range events {
+		log.Info().Str("camera", monitor.Name).Str("type", ev.Type).Str("zone", ev.Zone).
+			Dur("after", ev.Duration).Msg("Stationary object detected")
+
+		where := monitor.Name
+		if ev.Zone != "" {
+			where = fmt.Sprintf("%s on %s", ev.Zone, monitor.Name)
+		}
+		eventType, message := notify.EventObjectAppeared, fmt.Sprintf("Object appeared in %s", where)
+		if ev.Type == motion.ObjectRemoved {
+			eventType, message = notify.EventObjectRemoved, fmt.Sprintf("Object removed from %s", where)
+		}
+
+		data := map[string]interface{}{"object": ev}
+		if ev.Before != nil {
+			data["before"] = ev.Before
+		}
+		if ev.After != nil {
+			data["after"] = ev.After
+		}
+		m.notifier.Send(notify.Event{
+			Type:    eventType,
+			Camera:  monitor.Name,
+			Time:    ev.Time,
+			Message: message,
+			Data:    data,
+		})
+	}
+
+	last := events[len(events)-1]
+	monitor.mu.Lock()
+	monitor.lastStationary = &last
+	monitor.mu.Unlock()
+}
@@ -0,0 +1,90 @@
+//go:build opencv
+
+package surveillance
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/kai5263499/droidcam-sentry/backend/internal/config"
+	"github.com/kai5263499/droidcam-sentry/backend/internal/notify"
+	"github.com/kai5263499/droidcam-sentry/backend/internal/tamper"
+	"github.com/rs/zerolog/log"
+	"gocv.io/x/gocv"
+)
+
+// tamperMessages describe each tamper kind in notifications
+var tamperMessages = map[string]string{
+	tamper.Covered:   "lens covered or image black",
+	tamper.Defocused: "image out of focus",
+	tamper.Moved:     "camera moved",
+}
+
+// checkTamper runs the tamper checks every interval_ms, whether or not
+// motion detection is on, and sends a high-priority notification when a
+// condition starts and a normal one when it clears
+func (m *Manager) checkTamper(monitor *CameraMonitor, camCfg config.CameraConfig, frame gocv.Mat) {
+	cfg := monitor.tamper.Config()
+	if !camCfg.TamperEnabled(cfg) || frame.Empty() {
+		return
+	}
+	if time.Since(monitor.tamperAt) < time.Duration(cfg.IntervalMs)*time.Millisecond {
+		return
+	}
+	monitor.tamperAt = time.Now()
+
+	events := monitor.tamper.Check(monitor.tamperAt, tamper.Thumbnail(frame))
+	if len(events) == 0 {
+		return
+	}
+	snapshot := encodeSnapshot(frame)
+
+	for _, ev := range events {
+		data := map[string]interface{}{"tamper": ev}
+		if ev.Cleared {
+			log.Info().Str("camera", monitor.Name).Str("kind", ev.Kind).Msg("Tamper condition cleared")
+			m.notifier.Send(notify.Event{
+				Type:    notify.EventTamperCleared,
+				Camera:  monitor.Name,
+				Time:    ev.Time,
+				Message: fmt.Sprintf("%s on %s: back to normal", tamperMessages[ev.Kind], monitor.Name),
+				Data:    data,
+			})
+			continue
+		}
+
+		log.Warn().Str("camera", monitor.Name).Str("kind", ev.Kind).Float64("value", ev.Value).
+			Float64("limit", ev.Limit).Msg("Camera tampering detected")
+		if snapshot != nil {
+			data["snapshot"] = snapshot
+		}
+		m.notifier.Send(notify.Event{
+			Type:     notify.EventTamper,
+			Priority: notify.PriorityHigh,
+			Camera:   monitor.Name,
+			Time:     ev.Time,
+			Message:  fmt.Sprintf("Tampering on %s: %s", monitor.Name, tamperMessages[ev.Kind]),
+			Labels:   []string{ev.Kind},
+			Data:     data,
+		})
+	}
+}
+
+// ResetTamperReference makes the camera's current view the tamper
+// reference, e.g. after moving it on purpose
+func (m *Manager) ResetTamperReference(cameraName string) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	monitor, exists := m.monitors[cameraName]
+	if !exists {
+		return fmt.Errorf("camera %s not found", cameraName)
+	}
+	if !monitor.running {
+		return fmt.Errorf("camera %s is not running", cameraName)
+	}
+
+	monitor.tamper.ResetReference()
+	log.Info().Str("camera", cameraName).Msg("Tamper reference reset")
+	return nil
+}
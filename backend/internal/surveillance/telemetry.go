@@ -0,0 +1,75 @@
+//go:build opencv
+
+package surveillance
+
+import (
+	"fmt"
+
+	"github.com/kai5263499/droidcam-sentry/backend/internal/config"
+	"github.com/kai5263499/droidcam-sentry/backend/internal/health"
+	"github.com/kai5263499/droidcam-sentry/backend/internal/notify"
+	"github.com/rs/zerolog/log"
+)
+
+// phoneAlertMessages describe each phone alert in notifications
+var phoneAlertMessages = map[string]string{
+	health.LowBattery:  "battery low",
+	health.NotCharging: "not charging",
+	health.Overheating: "overheating",
+}
+
+// pollTelemetry reads a camera's battery info, adds it to the camera's
+// history and sends a high-priority notification when an alert starts and
+// a normal one when it clears
+func (m *Manager) pollTelemetry(camCfg config.CameraConfig, cfg config.HealthConfig) {
+	phone := m.phone(camCfg.Name, true)
+
+	t, err := m.healthChecker.Telemetry(camCfg.URL)
+	if err != nil {
+		log.Debug().Str("camera", camCfg.Name).Err(err).Msg("Phone telemetry unavailable")
+		phone.Failed(err)
+		return
+	}
+
+	for _, alert := range phone.Record(cfg, t) {
+		data := map[string]interface{}{"alert": alert, "telemetry": t}
+		if alert.Cleared {
+			log.Info().Str("camera", camCfg.Name).Str("kind", alert.Kind).Msg("Phone alert cleared")
+			m.notifier.Send(notify.Event{
+				Type:    notify.EventPhoneCleared,
+				Camera:  camCfg.Name,
+				Time:    alert.Time,
+				Message: fmt.Sprintf("%s phone %s: back to normal", camCfg.Name, phoneAlertMessages[alert.Kind]),
+				Labels:  []string{alert.Kind},
+				Data:    data,
+			})
+			continue
+		}
+
+		log.Warn().Str("camera", camCfg.Name).Str("kind", alert.Kind).Float64("value", alert.Value).
+			Float64("limit", alert.Limit).Msg("Phone alert")
+		m.notifier.Send(notify.Event{
+			Type:     notify.EventPhone,
+			Priority: notify.PriorityHigh,
+			Camera:   camCfg.Name,
+			Time:     alert.Time,
+			Message:  fmt.Sprintf("%s phone %s", camCfg.Name, phoneAlertMessages[alert.Kind]),
+			Labels:   []string{alert.Kind},
+			Data:     data,
+		})
+	}
+}
+
+// phone returns a camera's telemetry, creating it if create is set and
+// returning nil otherwise for cameras that were never polled
+func (m *Manager) phone(cameraName string, create bool) *health.Phone {
+	m.healthMu.Lock()
+	defer m.healthMu.Unlock()
+
+	phone, ok := m.phones[cameraName]
+	if !ok && create {
+		phone = health.NewPhone()
+		m.phones[cameraName] = phone
+	}
+	return phone
+}
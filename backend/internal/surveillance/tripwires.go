@@ -0,0 +1,79 @@
+//go:build opencv
+
+package surveillance
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/kai5263499/droidcam-sentry/backend/internal/config"
+	"github.com/kai5263499/droidcam-sentry/backend/internal/counters"
+	"github.com/kai5263499/droidcam-sentry/backend/internal/motion"
+	"github.com/kai5263499/droidcam-sentry/backend/internal/notify"
+	"github.com/rs/zerolog/log"
+)
+
+// handleCrossings counts tripwire crossings and sends their notifications.
+// It reports whether one of them should start a recording.
+func (m *Manager) handleCrossings(monitor *CameraMonitor, camCfg config.CameraConfig, crossings []motion.Crossing) bool {
+	record := false
+	for _, c := range crossings {
+		if err := m.counters.Add(monitor.Name, c.Line, c.Direction, c.Time); err != nil {
+			log.Error().Str("camera", monitor.Name).Err(err).Msg("Failed to save crossing counters")
+		}
+
+		tripwire, ok := camCfg.Tripwire(c.Line)
+		if !ok || !tripwire.Triggers(c.Direction, c.Label) {
+			continue
+		}
+		log.Info().Str("camera", monitor.Name).Str("line", c.Line).Str("direction", c.Direction).
+			Int("track", c.TrackID).Str("label", c.Label).Msg("Tripwire crossed")
+
+		if tripwire.Record {
+			record = true
+		}
+		if tripwire.Notify {
+			m.notifyCrossing(monitor.Name, c)
+		}
+	}
+	return record
+}
+
+// notifyCrossing sends a tripwire crossing notification
+func (m *Manager) notifyCrossing(cameraName string, c motion.Crossing) {
+	what := "Object"
+	var labels []string
+	if c.Label != "" {
+		what = c.Label
+		labels = []string{c.Label}
+	}
+
+	m.notifier.Send(notify.Event{
+		Type:    notify.EventCrossing,
+		Camera:  cameraName,
+		Time:    c.Time,
+		Message: fmt.Sprintf("%s crossed %s (%s) on %s", what, c.Line, c.Direction, cameraName),
+		Labels:  labels,
+		Data:    c,
+	})
+}
+
+// Counters returns the daily tripwire crossing counts of a camera for the
+// last days days, keyed by date and line
+func (m *Manager) Counters(cameraName string, days int) (map[string]map[string]counters.Count, error) {
+	if _, err := m.cfg.Camera(cameraName); err != nil {
+		return nil, err
+	}
+	return m.counters.Days(cameraName, time.Now(), days), nil
+}
+
+// loadCounters opens the crossing counters file, falling back to counting in
+// memory only if it can't be read
+func loadCounters(path string) *counters.Store {
+	store, err := counters.Load(path)
+	if err != nil {
+		log.Error().Str("path", path).Err(err).Msg("Failed to load crossing counters, counting in memory")
+		store, _ = counters.Load("")
+	}
+	return store
+}
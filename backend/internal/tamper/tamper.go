@@ -0,0 +1,312 @@
+// Package tamper detects a covered, defocused or moved camera from
+// downscaled greyscale frames.
+package tamper
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/kai5263499/droidcam-sentry/backend/internal/config"
+)
+
+// Tamper kinds
+const (
+	// Covered is a lens that was covered or a frame that went black
+	Covered = "covered"
+	// Defocused is an image that lost most of its usual sharpness
+	Defocused = "defocused"
+	// Moved is a view that no longer matches the reference frame
+	Moved = "moved"
+)
+
+// Width and Height are the size of the greyscale thumbnails that are checked
+const (
+	Width  = 320
+	Height = 240
+)
+
+const (
+	// sharpnessWarmup is how many checks build the sharpness baseline
+	// before defocus is judged
+	sharpnessWarmup = 10
+	// sharpnessRate is how quickly the sharpness baseline follows the scene
+	sharpnessRate = 0.05
+	// similarityScale shrinks thumbnails further before comparing them with
+	// the reference, so small shakes don't count as a move
+	similarityScale = 4
+)
+
+// kinds is the order conditions are checked and reported in
+var kinds = []string{Covered, Defocused, Moved}
+
+// Event reports a tamper condition that lasted the hold time, or one that
+// has cleared again
+type Event struct {
+	Kind    string `json:"kind"`
+	Cleared bool   `json:"cleared,omitempty"`
+	// Since is when the condition was first seen
+	Since time.Time `json:"since"`
+	Time  time.Time `json:"time"`
+	// Value is the measurement that tripped the check and Limit what it was
+	// compared with
+	Value float64 `json:"value"`
+	Limit float64 `json:"limit"`
+}
+
+// Measurement is the outcome of the latest check
+type Measurement struct {
+	StdDev    float64 `json:"stddev"`
+	Sharpness float64 `json:"sharpness"`
+	// Baseline is the usual sharpness of the scene
+	Baseline float64 `json:"baseline"`
+	// Similarity is the correlation with the reference frame, 1 without one
+	Similarity float64 `json:"similarity"`
+}
+
+type condition struct {
+	since  time.Time
+	active bool
+}
+
+// Checker follows one camera's thumbnails and reports tamper conditions
+// once they have lasted HoldSeconds. It is safe for concurrent use.
+type Checker struct {
+	mu          sync.Mutex
+	cfg         config.TamperConfig
+	reference   []byte
+	referenceAt time.Time
+	baseline    float64
+	samples     int
+	last        Measurement
+	conditions  map[string]*condition
+}
+
+// NewChecker creates a checker without a reference frame; the first
+// undisturbed thumbnail becomes the reference
+func NewChecker(cfg config.TamperConfig) *Checker {
+	c := &Checker{cfg: cfg, conditions: make(map[string]*condition)}
+	for _, kind := range kinds {
+		c.conditions[kind] = &condition{}
+	}
+	return c
+}
+
+// Config returns the thresholds in use
+func (c *Checker) Config() config.TamperConfig {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.cfg
+}
+
+// Configure replaces the thresholds, keeping the reference and baseline
+func (c *Checker) Configure(cfg config.TamperConfig) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cfg = cfg
+}
+
+// Check measures a Width x Height greyscale thumbnail and returns the
+// conditions that started or cleared
+func (c *Checker) Check(now time.Time, gray []byte) []Event {
+	if len(gray) != Width*Height {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stddev, sharpness := Measure(gray, Width, Height)
+	similarity := 1.0
+	if c.reference != nil {
+		similarity = Similarity(c.reference, gray, Width, Height)
+	}
+	c.last = Measurement{StdDev: stddev, Sharpness: sharpness, Baseline: c.baseline, Similarity: similarity}
+
+	// A covered lens also looks blurred and moved, so it hides the others
+	covered := stddev < c.cfg.MinStdDev
+	defocusLimit := c.baseline * c.cfg.DefocusRatio
+	defocused := !covered && c.samples >= sharpnessWarmup && sharpness < defocusLimit
+	moved := !covered && c.reference != nil && similarity < c.cfg.MinSimilarity
+
+	var events []Event
+	for _, check := range []struct {
+		kind         string
+		bad          bool
+		value, limit float64
+	}{
+		{Covered, covered, stddev, c.cfg.MinStdDev},
+		{Defocused, defocused, sharpness, defocusLimit},
+		{Moved, moved, similarity, c.cfg.MinSimilarity},
+	} {
+		if ev := c.update(now, check.kind, check.bad, check.value, check.limit); ev != nil {
+			events = append(events, *ev)
+		}
+	}
+
+	// Baselines only learn from undisturbed frames
+	if c.disturbed() {
+		return events
+	}
+	if c.samples == 0 {
+		c.baseline = sharpness
+	} else {
+		c.baseline += (sharpness - c.baseline) * sharpnessRate
+	}
+	c.samples++
+	refresh := time.Duration(c.cfg.ReferenceMinutes) * time.Minute
+	if c.reference == nil || now.Sub(c.referenceAt) >= refresh {
+		c.reference = append(c.reference[:0], gray...)
+		c.referenceAt = now
+	}
+	return events
+}
+
+// update advances one condition and returns its event if it started or
+// cleared
+func (c *Checker) update(now time.Time, kind string, bad bool, value, limit float64) *Event {
+	cond := c.conditions[kind]
+	if !bad {
+		wasActive := cond.active
+		since := cond.since
+		*cond = condition{}
+		if !wasActive {
+			return nil
+		}
+		return &Event{Kind: kind, Cleared: true, Since: since, Time: now, Value: value, Limit: limit}
+	}
+
+	if cond.since.IsZero() {
+		cond.since = now
+	}
+	hold := time.Duration(c.cfg.HoldSeconds) * time.Second
+	if cond.active || now.Sub(cond.since) < hold {
+		return nil
+	}
+	cond.active = true
+	return &Event{Kind: kind, Since: cond.since, Time: now, Value: value, Limit: limit}
+}
+
+// disturbed reports whether any condition is pending or active
+func (c *Checker) disturbed() bool {
+	for _, cond := range c.conditions {
+		if !cond.since.IsZero() {
+			return true
+		}
+	}
+	return false
+}
+
+// Active returns the conditions currently reported
+func (c *Checker) Active() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	active := []string{}
+	for _, kind := range kinds {
+		if c.conditions[kind].active {
+			active = append(active, kind)
+		}
+	}
+	return active
+}
+
+// Last returns the latest measurement
+func (c *Checker) Last() Measurement {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.last
+}
+
+// ResetReference drops the reference frame, e.g. after the camera was
+// moved on purpose; the next thumbnail becomes the new reference
+func (c *Checker) ResetReference() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.reference = nil
+}
+
+// Measure returns the grey-level standard deviation of an image and its
+// sharpness, the variance of its 4-neighbour Laplacian
+func Measure(gray []byte, width, height int) (stddev, sharpness float64) {
+	if len(gray) == 0 || len(gray) != width*height {
+		return 0, 0
+	}
+
+	var sum, sumSq float64
+	for _, v := range gray {
+		sum += float64(v)
+		sumSq += float64(v) * float64(v)
+	}
+	n := float64(len(gray))
+	mean := sum / n
+	stddev = math.Sqrt(math.Max(sumSq/n-mean*mean, 0))
+
+	var lapSum, lapSq float64
+	count := 0
+	for y := 1; y < height-1; y++ {
+		for x := 1; x < width-1; x++ {
+			i := y*width + x
+			lap := float64(gray[i-1]) + float64(gray[i+1]) + float64(gray[i-width]) + float64(gray[i+width]) - 4*float64(gray[i])
+			lapSum += lap
+			lapSq += lap * lap
+			count++
+		}
+	}
+	if count == 0 {
+		return stddev, 0
+	}
+	lapMean := lapSum / float64(count)
+	sharpness = lapSq/float64(count) - lapMean*lapMean
+	return stddev, sharpness
+}
+
+// Similarity is the normalised cross-correlation of two images of the same
+// size after shrinking them by similarityScale: 1 for the same view, even
+// under different lighting, and near 0 for unrelated views
+func Similarity(a, b []byte, width, height int) float64 {
+	if len(a) != width*height || len(b) != width*height {
+		return 0
+	}
+	sa, w, h := shrink(a, width, height, similarityScale)
+	sb, _, _ := shrink(b, width, height, similarityScale)
+	if w*h == 0 {
+		return 0
+	}
+
+	var meanA, meanB float64
+	for i := range sa {
+		meanA += sa[i]
+		meanB += sb[i]
+	}
+	meanA /= float64(len(sa))
+	meanB /= float64(len(sb))
+
+	var cov, varA, varB float64
+	for i := range sa {
+		da, db := sa[i]-meanA, sb[i]-meanB
+		cov += da * db
+		varA += da * da
+		varB += db * db
+	}
+	if varA == 0 || varB == 0 {
+		return 0
+	}
+	return cov / math.Sqrt(varA*varB)
+}
+
+// shrink averages factor x factor blocks of an image
+func shrink(gray []byte, width, height, factor int) ([]float64, int, int) {
+	w, h := width/factor, height/factor
+	out := make([]float64, w*h)
+	for y := 0; y < h*factor; y++ {
+		for x := 0; x < w*factor; x++ {
+			out[(y/factor)*w+x/factor] += float64(gray[y*width+x])
+		}
+	}
+	area := float64(factor * factor)
+	for i := range out {
+		out[i] /= area
+	}
+	return out, w, h
+}
@@ -0,0 +1,143 @@
+package tamper
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kai5263499/droidcam-sentry/backend/internal/config"
+)
+
+var testConfig = config.TamperConfig{
+	Enabled:          true,
+	HoldSeconds:      5,
+	MinStdDev:        10,
+	DefocusRatio:     0.3,
+	MinSimilarity:    0.5,
+	ReferenceMinutes: 10,
+}
+
+// scene is a blocky pseudo-random test image
+func scene(seed uint32) []byte {
+	gray := make([]byte, Width*Height)
+	block := make([]byte, (Width/16)*(Height/16))
+	for i := range block {
+		seed = seed*1664525 + 1013904223
+		block[i] = byte(seed >> 24)
+	}
+	for y := 0; y < Height; y++ {
+		for x := 0; x < Width; x++ {
+			gray[y*Width+x] = block[(y/16)*(Width/16)+x/16]
+		}
+	}
+	return gray
+}
+
+func uniform(v byte) []byte {
+	gray := make([]byte, Width*Height)
+	for i := range gray {
+		gray[i] = v
+	}
+	return gray
+}
+
+// blur box-blurs an image with a 9x9 kernel
+func blur(gray []byte) []byte {
+	out := make([]byte, len(gray))
+	for y := 0; y < Height; y++ {
+		for x := 0; x < Width; x++ {
+			sum, n := 0, 0
+			for dy := -4; dy <= 4; dy++ {
+				for dx := -4; dx <= 4; dx++ {
+					if xx, yy := x+dx, y+dy; xx >= 0 && xx < Width && yy >= 0 && yy < Height {
+						sum += int(gray[yy*Width+xx])
+						n++
+					}
+				}
+			}
+			out[y*Width+x] = byte(sum / n)
+		}
+	}
+	return out
+}
+
+// run feeds frame once a second for the given number of seconds
+func run(c *Checker, start time.Time, from, seconds int, frame []byte) []Event {
+	var events []Event
+	for s := from; s < from+seconds; s++ {
+		events = append(events, c.Check(start.Add(time.Duration(s)*time.Second), frame)...)
+	}
+	return events
+}
+
+func TestCoveredAfterHoldAndCleared(t *testing.T) {
+	c := NewChecker(testConfig)
+	start := time.Unix(1000, 0)
+	normal := scene(1)
+
+	if events := run(c, start, 0, 20, normal); len(events) != 0 {
+		t.Fatalf("Expected no events for an undisturbed view, got %v", events)
+	}
+	if events := run(c, start, 20, 4, uniform(3)); len(events) != 0 {
+		t.Fatalf("Expected nothing before the hold time, got %v", events)
+	}
+	events := run(c, start, 24, 10, uniform(3))
+	if len(events) != 1 || events[0].Kind != Covered || events[0].Cleared {
+		t.Fatalf("Expected one covered event, got %v", events)
+	}
+	if !events[0].Since.Equal(start.Add(20 * time.Second)) {
+		t.Errorf("Expected the event to start when the lens was covered, got %v", events[0].Since)
+	}
+	if active := c.Active(); len(active) != 1 || active[0] != Covered {
+		t.Errorf("Expected covered to be active, got %v", active)
+	}
+
+	events = run(c, start, 34, 1, normal)
+	if len(events) != 1 || events[0].Kind != Covered || !events[0].Cleared {
+		t.Fatalf("Expected the covered event to clear, got %v", events)
+	}
+}
+
+func TestDefocused(t *testing.T) {
+	c := NewChecker(testConfig)
+	start := time.Unix(1000, 0)
+	normal := scene(2)
+
+	run(c, start, 0, sharpnessWarmup+5, normal)
+	events := run(c, start, 20, 10, blur(normal))
+	if len(events) != 1 || events[0].Kind != Defocused {
+		t.Fatalf("Expected one defocused event, got %v", events)
+	}
+}
+
+func TestMovedAndResetReference(t *testing.T) {
+	c := NewChecker(testConfig)
+	start := time.Unix(1000, 0)
+
+	run(c, start, 0, 15, scene(3))
+	turned := scene(4)
+	events := run(c, start, 15, 10, turned)
+	if len(events) != 1 || events[0].Kind != Moved {
+		t.Fatalf("Expected one moved event, got %v", events)
+	}
+
+	// Accepting the new view clears the alarm
+	c.ResetReference()
+	events = run(c, start, 25, 2, turned)
+	if len(events) != 1 || events[0].Kind != Moved || !events[0].Cleared {
+		t.Fatalf("Expected the moved event to clear after a reset, got %v", events)
+	}
+}
+
+func TestSimilarityIgnoresLighting(t *testing.T) {
+	view := scene(5)
+	darker := make([]byte, len(view))
+	for i, v := range view {
+		darker[i] = v/2 + 10
+	}
+	if s := Similarity(view, darker, Width, Height); s < 0.99 {
+		t.Errorf("Expected a darker copy of the view to match, got %.2f", s)
+	}
+	if s := Similarity(view, scene(6), Width, Height); s > 0.5 {
+		t.Errorf("Expected a different view not to match, got %.2f", s)
+	}
+}
@@ -0,0 +1,22 @@
+//go:build opencv
+
+package tamper
+
+import (
+	"image"
+
+	"gocv.io/x/gocv"
+)
+
+// Thumbnail scales a BGR frame down to the Width x Height greyscale image
+// the checker works on
+func Thumbnail(frame gocv.Mat) []byte {
+	if frame.Empty() {
+		return nil
+	}
+	small := gocv.NewMat()
+	defer small.Close()
+	gocv.Resize(frame, &small, image.Pt(Width, Height), 0, 0, gocv.InterpolationArea)
+	gocv.CvtColor(small, &small, gocv.ColorBGRToGray)
+	return small.ToBytes()
+}
@@ -3,11 +3,17 @@
 package main
 
 import (
+	"context"
+	"flag"
+	"fmt"
+	"net"
 	"net/http"
 	_ "net/http/pprof"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
+	"time"
 
 	"github.com/felixge/fgprof"
 	"github.com/rs/zerolog/log"
@@ -15,6 +21,7 @@ import (
 	"github.com/kai5263499/droidcam-sentry/backend/internal/config"
 	"github.com/kai5263499/droidcam-sentry/backend/internal/logger"
 	"github.com/kai5263499/droidcam-sentry/backend/internal/server"
+	"github.com/kai5263499/droidcam-sentry/backend/internal/state"
 	"github.com/kai5263499/droidcam-sentry/backend/internal/surveillance"
 )
 
@@ -29,7 +36,6 @@ import (
 // @license.name MIT
 // @license.url https://opensource.org/licenses/MIT
 
-// @host 192.168.2.149:8080
 // @BasePath /
 // @schemes http
 
@@ -42,49 +48,173 @@ import (
 // @tag.name System
 // @tag.description System status and configuration
 
+const version = "0.1.0"
+
+// options holds the global command-line flags
+type options struct {
+	configPath string
+	listen     string
+	logLevel   string
+	logFormat  string
+	debug      bool
+	debugAddr  string
+}
+
+// command is a sentry subcommand
+type command struct {
+	name    string
+	usage   string
+	summary string
+	run     func(opts options, args []string) error
+}
+
+var commands = []command{
+	{"serve", "serve", "Run the surveillance service and API (default)", runServe},
+	{"validate-config", "validate-config", "Validate the configuration file and exit", runValidateConfig},
+	{"list-recordings", "list-recordings [-camera name] [-json]", "List recordings for all or one camera", runListRecordings},
+	{"purge", "purge [-dry-run] [-older-than 168h]", "Delete recordings older than the retention period", runPurge},
+	{"reindex", "reindex", "Convert leftover AVI files and probe recording durations", runReindex},
+	{"snapshot", "snapshot [-o file.jpg] <camera>", "Save a single frame from a camera", runSnapshot},
+	{"replay", "replay [-camera name] [-motion json] [-format json|csv] [-out path] [file...]", "Replay recordings through the detector with candidate settings", runReplay},
+}
+
 func main() {
-	// Initialize logger with INFO level
-	logger.Init("info")
+	opts := options{}
+	flags := flag.NewFlagSet("droidcam-sentry", flag.ExitOnError)
+	flags.StringVar(&opts.configPath, "config", "config.yaml", "Path to the configuration file")
+	flags.StringVar(&opts.listen, "listen", "", "API listen address (host:port), overrides server.host and server.port")
+	flags.StringVar(&opts.logLevel, "log-level", "info", "Log level: debug, info, warn or error")
+	flags.StringVar(&opts.logFormat, "log-format", "console", "Log format: console or json")
+	flags.BoolVar(&opts.debug, "debug", false, "Enable the pprof/fgprof debug server")
+	flags.StringVar(&opts.debugAddr, "debug-addr", ":6060", "Debug server listen address")
+	flags.Usage = func() {
+		out := flags.Output()
+		fmt.Fprintf(out, "Usage: droidcam-sentry [flags] <command> [command flags]\n\nCommands:\n")
+		for _, cmd := range commands {
+			fmt.Fprintf(out, "  %-40s %s\n", cmd.usage, cmd.summary)
+		}
+		fmt.Fprintf(out, "\nFlags:\n")
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(os.Args[1:])
 
-	// Start pprof and fgprof server on separate port for performance profiling
-	go func() {
-		log.Info().Msg("Starting profiling server on :6060")
-		log.Info().Str("pprof", "http://localhost:6060/debug/pprof").Msg("Standard pprof available")
-		log.Info().Str("fgprof", "http://localhost:6060/debug/fgprof").Msg("Full goroutine profiler available")
+	logger.Init(opts.logLevel, opts.logFormat)
 
-		// Register fgprof handler
-		http.DefaultServeMux.Handle("/debug/fgprof", fgprof.Handler())
+	name := "serve"
+	args := flags.Args()
+	if len(args) > 0 {
+		name, args = args[0], args[1:]
+	}
 
-		if err := http.ListenAndServe(":6060", nil); err != nil {
-			log.Error().Err(err).Msg("Profiling server error")
+	for _, cmd := range commands {
+		if cmd.name == name {
+			if err := cmd.run(opts, args); err != nil {
+				log.Fatal().Err(err).Str("command", name).Msg("Command failed")
+			}
+			return
 		}
-	}()
+	}
+
+	fmt.Fprintf(os.Stderr, "Unknown command %q\n\n", name)
+	flags.Usage()
+	os.Exit(2)
+}
+
+// loadConfig loads the configuration
+func loadConfig(opts options) (*config.Config, error) {
+	return config.Load(opts.configPath)
+}
+
+// listenAddr returns the API listen address: -listen if given, otherwise
+// server.host and server.port. The flag only applies to this run and is
+// never written to the config file.
+func listenAddr(opts options, cfg *config.Config) (string, error) {
+	if opts.listen == "" {
+		return net.JoinHostPort(cfg.Server.Host, strconv.Itoa(cfg.Server.Port)), nil
+	}
+	_, portStr, err := net.SplitHostPort(opts.listen)
+	if err != nil {
+		return "", fmt.Errorf("invalid -listen address: %w", err)
+	}
+	if _, err := strconv.Atoi(portStr); err != nil {
+		return "", fmt.Errorf("invalid -listen port: %w", err)
+	}
+	return opts.listen, nil
+}
+
+// startDebugServer starts pprof and fgprof on a separate port for performance profiling
+func startDebugServer(addr string) {
+	log.Info().Str("addr", addr).Msg("Starting profiling server")
+	log.Info().Str("pprof", "http://"+displayAddr(addr)+"/debug/pprof").Msg("Standard pprof available")
+	log.Info().Str("fgprof", "http://"+displayAddr(addr)+"/debug/fgprof").Msg("Full goroutine profiler available")
+
+	// Register fgprof handler
+	http.DefaultServeMux.Handle("/debug/fgprof", fgprof.Handler())
+
+	if err := http.ListenAndServe(addr, nil); err != nil {
+		log.Error().Err(err).Msg("Profiling server error")
+	}
+}
+
+// displayAddr turns a listen address into one a browser can open
+func displayAddr(addr string) string {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = "localhost"
+	}
+	return net.JoinHostPort(host, port)
+}
+
+func runServe(opts options, _ []string) error {
+	if opts.debug {
+		go startDebugServer(opts.debugAddr)
+	}
 
 	// Load configuration
-	cfg, err := config.Load("config.yaml")
+	cfg, err := loadConfig(opts)
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	apiAddr, err := listenAddr(opts, cfg)
 	if err != nil {
-		log.Fatal().Err(err).Msg("Failed to load configuration")
+		return err
 	}
 
-	log.Info().Str("version", "0.1.0").Msg("Starting droidcam-sentry")
+	log.Info().Str("version", version).Msg("Starting droidcam-sentry")
 	log.Info().Int("cameras", len(cfg.Cameras)).Msg("Loaded cameras")
 
+	// Load runtime camera state saved by the previous run
+	store, err := state.Load(cfg.StoragePath(cfg.Storage.StateFile))
+	if err != nil {
+		return fmt.Errorf("failed to load camera state: %w", err)
+	}
+
 	// Initialize surveillance manager
-	survMgr := surveillance.NewManager(cfg)
+	survMgr := surveillance.NewManager(cfg, store)
 	if err := survMgr.Start(); err != nil {
-		log.Fatal().Err(err).Msg("Failed to start surveillance")
+		return fmt.Errorf("failed to start surveillance: %w", err)
 	}
 	defer survMgr.Stop()
 
 	// Start HTTP API server
-	apiServer := server.New(cfg, survMgr)
+	apiServer := server.New(cfg, survMgr, apiAddr)
 	go func() {
-		if err := apiServer.Start(); err != nil {
+		if err := apiServer.Start(); err != nil && err != http.ErrServerClosed {
 			log.Fatal().Err(err).Msg("Failed to start API server")
 		}
 	}()
 
-	log.Info().Str("url", "http://192.168.2.149:8080/swagger/index.html").Msg("Swagger UI available")
+	log.Info().Str("url", "http://"+displayAddr(apiAddr)+"/swagger/index.html").Msg("Swagger UI available")
+
+	// Reload configuration on SIGHUP or when the file changes on disk
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	hupChan := make(chan os.Signal, 1)
+	signal.Notify(hupChan, syscall.SIGHUP)
+	go cfg.Watch(ctx, 2*time.Second, hupChan)
 
 	// Wait for interrupt signal
 	sigChan := make(chan os.Signal, 1)
@@ -93,4 +223,5 @@ func main() {
 
 	log.Info().Msg("Shutting down gracefully...")
 	_ = apiServer.Stop()
+	return nil
 }
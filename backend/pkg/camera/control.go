@@ -0,0 +1,213 @@
+package camera
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Control actions
+const (
+	ActionTorch            = "torch"
+	ActionZoom             = "zoom"
+	ActionAutofocus        = "autofocus"
+	ActionExposureLock     = "exposure_lock"
+	ActionWhiteBalanceLock = "white_balance_lock"
+)
+
+// DroidCam's control endpoints, relative to the stream's host. They are all
+// PUT requests without a body.
+const (
+	pathAutofocus      = "/v1/camera/autofocus"
+	pathTorchToggle    = "/v1/camera/torch_toggle"
+	pathZoomIn         = "/v1/camera/zoom_in"
+	pathZoomOut        = "/v1/camera/zoom_out"
+	pathZoomLevel      = "/v1/camera/mzoom/"
+	pathExposureLock   = "/v1/camera/el_lock"
+	pathExposureUnlock = "/v1/camera/el_unlock"
+	pathWBLock         = "/v1/camera/wb_lock"
+	pathWBUnlock       = "/v1/camera/wb_unlock"
+)
+
+// ErrInvalidCommand is returned for commands with an unknown action or value
+var ErrInvalidCommand = errors.New("invalid control command")
+
+// Command is one control operation
+type Command struct {
+	Action string `json:"action"`
+	// Value is "on", "off" or "toggle" for the torch, "on" or "off" for the
+	// locks, and "in", "out" or a zoom level such as "2.5" for zoom
+	Value string `json:"value,omitempty"`
+}
+
+// Validate checks the action and its value
+func (c Command) Validate() error {
+	switch c.Action {
+	case ActionTorch:
+		if c.Value == "on" || c.Value == "off" || c.Value == "toggle" {
+			return nil
+		}
+		return fmt.Errorf("%w: torch value must be on, off or toggle", ErrInvalidCommand)
+	case ActionZoom:
+		if c.Value == "in" || c.Value == "out" {
+			return nil
+		}
+		if level, err := strconv.ParseFloat(c.Value, 64); err == nil && level >= 1 {
+			return nil
+		}
+		return fmt.Errorf("%w: zoom value must be in, out or a level of at least 1", ErrInvalidCommand)
+	case ActionAutofocus:
+		return nil
+	case ActionExposureLock, ActionWhiteBalanceLock:
+		if c.Value == "on" || c.Value == "off" {
+			return nil
+		}
+		return fmt.Errorf("%w: %s value must be on or off", ErrInvalidCommand, c.Action)
+	}
+	return fmt.Errorf("%w: unknown action %q", ErrInvalidCommand, c.Action)
+}
+
+// Control drives a phone's camera through DroidCam's HTTP control
+// endpoints. DroidCam can only toggle the torch, so the client remembers
+// whether it turned it on. It is safe for concurrent use.
+type Control struct {
+	baseURL string
+	client  *http.Client
+	mu      sync.Mutex
+	torch   bool
+}
+
+// NewControl creates a control client for the phone serving streamURL
+func NewControl(streamURL string, timeout time.Duration) (*Control, error) {
+	u, err := url.Parse(streamURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid camera URL: %w", err)
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid camera URL %q", streamURL)
+	}
+	return &Control{
+		baseURL: u.Scheme + "://" + u.Host,
+		client:  &http.Client{Timeout: timeout},
+	}, nil
+}
+
+// Do validates and runs a command
+func (c *Control) Do(ctx context.Context, cmd Command) error {
+	if err := cmd.Validate(); err != nil {
+		return err
+	}
+
+	switch cmd.Action {
+	case ActionTorch:
+		if cmd.Value == "toggle" {
+			return c.ToggleTorch(ctx)
+		}
+		return c.Torch(ctx, cmd.Value == "on")
+	case ActionZoom:
+		switch cmd.Value {
+		case "in":
+			return c.ZoomIn(ctx)
+		case "out":
+			return c.ZoomOut(ctx)
+		}
+		level, _ := strconv.ParseFloat(cmd.Value, 64)
+		return c.SetZoom(ctx, level)
+	case ActionAutofocus:
+		return c.Autofocus(ctx)
+	case ActionExposureLock:
+		return c.LockExposure(ctx, cmd.Value == "on")
+	default:
+		return c.LockWhiteBalance(ctx, cmd.Value == "on")
+	}
+}
+
+// Torch switches the LED torch on or off; it does nothing if the torch is
+// already known to be in that state
+func (c *Control) Torch(ctx context.Context, on bool) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.torch == on {
+		return nil
+	}
+	if err := c.put(ctx, pathTorchToggle); err != nil {
+		return err
+	}
+	c.torch = on
+	return nil
+}
+
+// ToggleTorch switches the LED torch over
+func (c *Control) ToggleTorch(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.put(ctx, pathTorchToggle); err != nil {
+		return err
+	}
+	c.torch = !c.torch
+	return nil
+}
+
+// TorchOn reports whether the torch was last switched on
+func (c *Control) TorchOn() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.torch
+}
+
+// ZoomIn zooms in one step
+func (c *Control) ZoomIn(ctx context.Context) error {
+	return c.put(ctx, pathZoomIn)
+}
+
+// ZoomOut zooms out one step
+func (c *Control) ZoomOut(ctx context.Context) error {
+	return c.put(ctx, pathZoomOut)
+}
+
+// SetZoom sets the zoom level, 1 being no zoom
+func (c *Control) SetZoom(ctx context.Context, level float64) error {
+	return c.put(ctx, pathZoomLevel+strconv.FormatFloat(level, 'f', 1, 64))
+}
+
+// Autofocus triggers a focus run
+func (c *Control) Autofocus(ctx context.Context) error {
+	return c.put(ctx, pathAutofocus)
+}
+
+// LockExposure locks or unlocks the exposure
+func (c *Control) LockExposure(ctx context.Context, locked bool) error {
+	if locked {
+		return c.put(ctx, pathExposureLock)
+	}
+	return c.put(ctx, pathExposureUnlock)
+}
+
+// LockWhiteBalance locks or unlocks the white balance
+func (c *Control) LockWhiteBalance(ctx context.Context, locked bool) error {
+	if locked {
+		return c.put(ctx, pathWBLock)
+	}
+	return c.put(ctx, pathWBUnlock)
+}
+
+func (c *Control) put(ctx context.Context, path string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, c.baseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("droidcam control request failed: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("droidcam %s returned %s", path, resp.Status)
+	}
+	return nil
+}
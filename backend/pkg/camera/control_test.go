@@ -0,0 +1,123 @@
+package camera
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeDroidCam records the control requests it receives
+type fakeDroidCam struct {
+	mu       sync.Mutex
+	requests []string
+	fail     bool
+}
+
+func (f *fakeDroidCam) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requests = append(f.requests, r.Method+" "+r.URL.Path)
+	if f.fail {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+}
+
+func (f *fakeDroidCam) Requests() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.requests...)
+}
+
+func newFake(t *testing.T) (*fakeDroidCam, *Control) {
+	fake := &fakeDroidCam{}
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+
+	ctrl, err := NewControl(srv.URL+"/video", time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fake, ctrl
+}
+
+func TestControlCommands(t *testing.T) {
+	fake, ctrl := newFake(t)
+	ctx := context.Background()
+
+	for _, cmd := range []Command{
+		{Action: ActionZoom, Value: "in"},
+		{Action: ActionZoom, Value: "2.5"},
+		{Action: ActionAutofocus},
+		{Action: ActionExposureLock, Value: "on"},
+		{Action: ActionWhiteBalanceLock, Value: "off"},
+	} {
+		if err := ctrl.Do(ctx, cmd); err != nil {
+			t.Fatalf("Expected %v to succeed, got %v", cmd, err)
+		}
+	}
+
+	want := []string{
+		"PUT /v1/camera/zoom_in",
+		"PUT /v1/camera/mzoom/2.5",
+		"PUT /v1/camera/autofocus",
+		"PUT /v1/camera/el_lock",
+		"PUT /v1/camera/wb_unlock",
+	}
+	got := fake.Requests()
+	if len(got) != len(want) {
+		t.Fatalf("Expected %d requests, got %v", len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Expected request %d to be %s, got %s", i, want[i], got[i])
+		}
+	}
+}
+
+func TestControlTorchKeepsState(t *testing.T) {
+	fake, ctrl := newFake(t)
+	ctx := context.Background()
+
+	for _, value := range []string{"on", "on", "off", "off", "toggle"} {
+		if err := ctrl.Do(ctx, Command{Action: ActionTorch, Value: value}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := len(fake.Requests()); n != 3 {
+		t.Errorf("Expected repeated on/off to be skipped (3 toggles), got %d", n)
+	}
+	if !ctrl.TorchOn() {
+		t.Error("Expected the final toggle to leave the torch on")
+	}
+}
+
+func TestControlErrors(t *testing.T) {
+	fake, ctrl := newFake(t)
+	ctx := context.Background()
+
+	if err := ctrl.Do(ctx, Command{Action: ActionTorch, Value: "bright"}); !errors.Is(err, ErrInvalidCommand) {
+		t.Errorf("Expected an invalid command error, got %v", err)
+	}
+	if err := ctrl.Do(ctx, Command{Action: "reboot"}); !errors.Is(err, ErrInvalidCommand) {
+		t.Errorf("Expected an unknown action to be rejected, got %v", err)
+	}
+	if len(fake.Requests()) != 0 {
+		t.Error("Expected invalid commands not to reach the phone")
+	}
+
+	fake.fail = true
+	if err := ctrl.Torch(ctx, true); err == nil {
+		t.Error("Expected a failed request to return an error")
+	}
+	if ctrl.TorchOn() {
+		t.Error("Expected the torch state to stay off after a failed toggle")
+	}
+
+	if _, err := NewControl("not a url", time.Second); err == nil {
+		t.Error("Expected a URL without scheme and host to be rejected")
+	}
+}
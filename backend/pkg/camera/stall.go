@@ -0,0 +1,101 @@
+package camera
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrStalled is returned by ReadFrame once the stream has kept delivering
+// the same frame for the stall timeout, e.g. because DroidCam went to the
+// background and repeats its last JPEG
+var ErrStalled = errors.New("stream stalled: frames stopped changing")
+
+// Thumbnail size frames are compared at
+const (
+	stallWidth  = 64
+	stallHeight = 48
+)
+
+// StallStatus describes whether a stream is stuck on one frame
+type StallStatus struct {
+	Stalled bool
+	// Since is when the frames stopped changing, set while stalled
+	Since time.Time
+	// Count is how many times the stream has stalled
+	Count int
+}
+
+// StallDetector flags a stream whose frames stop changing. Frames are
+// compared as small greyscale thumbnails.
+type StallDetector struct {
+	// Timeout is how long frames may stay unchanged; zero disables the check
+	Timeout time.Duration
+	// MaxDiff is the mean grey-level difference (0-255) between consecutive
+	// thumbnails that still counts as unchanged
+	MaxDiff float64
+
+	last      []byte
+	unchanged time.Time
+	fired     bool
+	status    StallStatus
+}
+
+// Update compares a thumbnail, which it keeps, with the previous one and
+// reports whether the stream should be reconnected: once per run of
+// unchanged frames lasting Timeout. A changed frame clears the stalled
+// state.
+func (d *StallDetector) Update(now time.Time, thumb []byte) bool {
+	previous := d.last
+	d.last = thumb
+	if previous == nil {
+		return false
+	}
+
+	if len(previous) != len(thumb) || FrameDiff(previous, thumb) > d.MaxDiff {
+		d.unchanged, d.fired = time.Time{}, false
+		d.status = StallStatus{Count: d.status.Count}
+		return false
+	}
+
+	if d.unchanged.IsZero() {
+		d.unchanged = now
+	}
+	if d.Timeout <= 0 || d.fired || now.Sub(d.unchanged) < d.Timeout {
+		return false
+	}
+	d.fired = true
+	if !d.status.Stalled {
+		d.status = StallStatus{Stalled: true, Since: d.unchanged, Count: d.status.Count + 1}
+	}
+	return true
+}
+
+// Restart forgets the previous frame after the stream was reopened. The
+// stalled state stays until a changed frame arrives, and a stream that is
+// still stuck fires again after another Timeout.
+func (d *StallDetector) Restart() {
+	d.last = nil
+	d.unchanged, d.fired = time.Time{}, false
+}
+
+// Status returns the current stall state
+func (d *StallDetector) Status() StallStatus {
+	return d.status
+}
+
+// FrameDiff is the mean absolute difference between two greyscale images of
+// the same size
+func FrameDiff(a, b []byte) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 255
+	}
+	var sum int
+	for i := range a {
+		d := int(a[i]) - int(b[i])
+		if d < 0 {
+			d = -d
+		}
+		sum += d
+	}
+	return float64(sum) / float64(len(a))
+}
@@ -0,0 +1,67 @@
+package camera
+
+import (
+	"testing"
+	"time"
+)
+
+func frame(v byte) []byte {
+	thumb := make([]byte, stallWidth*stallHeight)
+	for i := range thumb {
+		thumb[i] = v + byte(i%7)
+	}
+	return thumb
+}
+
+func TestStallAfterTimeout(t *testing.T) {
+	d := &StallDetector{Timeout: 10 * time.Second}
+	start := time.Unix(1000, 0)
+
+	fired := 0
+	for s := 0; s <= 15; s++ {
+		if d.Update(start.Add(time.Duration(s)*time.Second), frame(50)) {
+			fired++
+		}
+	}
+	if fired != 1 {
+		t.Fatalf("Expected the stall to fire once, got %d", fired)
+	}
+	status := d.Status()
+	if !status.Stalled || status.Count != 1 || !status.Since.Equal(start.Add(time.Second)) {
+		t.Errorf("Expected a stall since the first repeated frame, got %+v", status)
+	}
+
+	// A reconnect that still delivers the same frame fires again
+	d.Restart()
+	fired = 0
+	for s := 20; s <= 35; s++ {
+		if d.Update(start.Add(time.Duration(s)*time.Second), frame(50)) {
+			fired++
+		}
+	}
+	if fired != 1 || d.Status().Count != 1 {
+		t.Errorf("Expected one more reconnect for the same stall, got %d and %+v", fired, d.Status())
+	}
+
+	d.Update(start.Add(40*time.Second), frame(90))
+	if status := d.Status(); status.Stalled || status.Count != 1 {
+		t.Errorf("Expected a changed frame to clear the stall, got %+v", status)
+	}
+}
+
+func TestStallToleratesNearIdenticalFrames(t *testing.T) {
+	d := &StallDetector{Timeout: 5 * time.Second, MaxDiff: 2}
+	start := time.Unix(1000, 0)
+
+	stalled := false
+	for s := 0; s <= 10; s++ {
+		// Re-encoding wobbles a grey level or so
+		stalled = d.Update(start.Add(time.Duration(s)*time.Second), frame(byte(100+s%2))) || stalled
+	}
+	if !stalled {
+		t.Error("Expected near-identical frames to stall")
+	}
+	if FrameDiff(frame(10), frame(10)) != 0 {
+		t.Error("Expected identical frames to have no difference")
+	}
+}
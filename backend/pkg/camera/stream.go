@@ -4,6 +4,7 @@ package camera
 
 import (
 	"fmt"
+	"image"
 	"sync"
 	"time"
 
@@ -20,6 +21,9 @@ type Stream struct {
 	lastError  error
 	frameCount int64
 	mu         sync.RWMutex
+	// stall watches for a stream stuck on one frame
+	stall   StallDetector
+	stallMu sync.Mutex
 }
 
 type StreamInfo struct {
@@ -58,6 +62,9 @@ func (s *Stream) Open() error {
 
 	s.capture = capture
 	s.isOpen = true
+	s.stallMu.Lock()
+	s.stall.Restart()
+	s.stallMu.Unlock()
 	log.Info().Str("camera", s.Name).Msg("Stream opened successfully")
 	return nil
 }
@@ -110,9 +117,43 @@ func (s *Stream) ReadFrame() (gocv.Mat, error) {
 	}
 
 	s.frameCount++
+	if s.stalled(tempFrame) {
+		log.Warn().Str("camera", s.Name).Time("since", s.StallStatus().Since).Msg("Stream stalled, frames stopped changing")
+		s.lastError = ErrStalled
+		return gocv.NewMat(), s.lastError
+	}
 	return tempFrame.Clone(), nil
 }
 
+// ConfigureStall sets how long frames may stay unchanged (zero disables the
+// check) and the mean grey-level difference that still counts as unchanged
+func (s *Stream) ConfigureStall(timeout time.Duration, maxDiff float64) {
+	s.stallMu.Lock()
+	defer s.stallMu.Unlock()
+	s.stall.Timeout = timeout
+	s.stall.MaxDiff = maxDiff
+}
+
+// StallStatus reports whether the stream is stuck on one frame
+func (s *Stream) StallStatus() StallStatus {
+	s.stallMu.Lock()
+	defer s.stallMu.Unlock()
+	return s.stall.Status()
+}
+
+// stalled compares a thumbnail of frame with the previous one and reports
+// whether the stream should be reconnected
+func (s *Stream) stalled(frame gocv.Mat) bool {
+	thumb := gocv.NewMat()
+	defer thumb.Close()
+	gocv.Resize(frame, &thumb, image.Pt(stallWidth, stallHeight), 0, 0, gocv.InterpolationArea)
+	gocv.CvtColor(thumb, &thumb, gocv.ColorBGRToGray)
+
+	s.stallMu.Lock()
+	defer s.stallMu.Unlock()
+	return s.stall.Update(time.Now(), thumb.ToBytes())
+}
+
 func (s *Stream) Close() error {
 	s.mu.Lock()
 	defer s.mu.Unlock()